@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/aslam-ep/go-e-commerce/config"
 	"github.com/aslam-ep/go-e-commerce/database"
+	"github.com/aslam-ep/go-e-commerce/internal/auth"
+	"github.com/aslam-ep/go-e-commerce/internal/inventory"
 	"github.com/aslam-ep/go-e-commerce/router"
+	"github.com/aslam-ep/go-e-commerce/utils"
 )
 
 func main() {
@@ -14,6 +19,38 @@ func main() {
 	config.LoadConfig()
 	log.Println("Loaded configuration values.")
 
+	// Load token signing keys
+	err := utils.LoadSigningKeys(
+		config.AppConfig.JWTSecret,
+		config.AppConfig.JWTPrivateKeyFile,
+		config.AppConfig.JWTPublicKeyFiles,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Loaded token signing keys.")
+
+	// Configure password hashing
+	argon2Params, err := utils.NewArgon2idParams(
+		config.AppConfig.Argon2Memory,
+		config.AppConfig.Argon2Iterations,
+		config.AppConfig.Argon2Parallelism,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = utils.ConfigurePasswordHasher(config.AppConfig.PasswordHasher, argon2Params, config.AppConfig.BcryptCost)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Choose the stock allocation strategy
+	allocator, err := inventory.NewAllocator(config.AppConfig.AllocationStrategy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Connect to database
 	db, err := database.ConnectDB()
 	if err != nil {
@@ -22,7 +59,19 @@ func main() {
 	defer db.Close()
 	log.Println("Connected to database.")
 
-	router := router.NewRouter(db)
+	// Purge the expired sessions in the background
+	if config.AppConfig.SessionSweepMinutes > 0 {
+		sweeper := auth.NewSweeper(auth.NewRepository(db), time.Duration(config.AppConfig.SessionSweepMinutes)*time.Minute)
+		go sweeper.Run(context.Background())
+	}
+
+	// Release the expired stock reservations in the background
+	if config.AppConfig.ReservationSweepSeconds > 0 {
+		sweeper := inventory.NewSweeper(inventory.NewRepository(db), time.Duration(config.AppConfig.ReservationSweepSeconds)*time.Second)
+		go sweeper.Run(context.Background())
+	}
+
+	router := router.NewRouter(db, allocator)
 	router.SetupRoutes()
 
 	// Start the server
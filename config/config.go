@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +21,90 @@ type Config struct {
 	DBTimeout    int
 	JWTSecret    string
 	APIRateLimit int
+
+	// JWTIssuer and JWTAudience are set on the issued tokens and required on the validated ones
+	JWTIssuer   string
+	JWTAudience string
+	// JWTLeeway is the allowed clock skew in seconds when validating the token timestamps
+	JWTLeeway int
+
+	// JWTPrivateKeyFile switches the token signing from HS256 to RS256 or EdDSA, based on the key type
+	JWTPrivateKeyFile string
+	// JWTPublicKeyFiles are the keys of the previous rotations which are still accepted
+	JWTPublicKeyFiles []string
+
+	// AppURL is the base url of the client app, used for the links sent to the users
+	AppURL string
+	// MFAIssuer is the issuer name shown in the authenticator apps
+	MFAIssuer string
+	// RequireEmailVerification blocks the login of users who haven't verified their email
+	RequireEmailVerification bool
+
+	// PasswordHasher selects the hashing of the new passwords, one of argon2id or bcrypt
+	PasswordHasher string
+	// Argon2Memory is in KiB
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+
+	// LoginMaxFailures and LoginIPMaxFailures are the failed logins allowed per account and per IP before a lockout
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	// LoginLockoutMinutes is how long a lockout lasts, and how long failures are remembered
+	LoginLockoutMinutes int
+
+	// MailDriver selects how emails are delivered, one of smtp, file or log
+	MailDriver   string
+	MailFrom     string
+	MailFileDir  string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// SessionSweepMinutes is how often the expired sessions and refresh tokens are purged
+	SessionSweepMinutes int
+
+	// OIDCProviders are the OpenID Connect providers users can log in with
+	OIDCProviders []OIDCProvider
+
+	// MagicLinkRoles are the roles allowed to log in with an emailed link, MagicLinkMinutes is how long a link lasts
+	MagicLinkRoles   []string
+	MagicLinkMinutes int
+
+	// SMSDriver selects how text messages are delivered, only log for now
+	SMSDriver string
+	// OTPMinutes is how long a phone code lasts, OTPMaxAttempts the wrong guesses allowed per code
+	// and OTPResendSeconds how long to wait before another code is sent
+	OTPMinutes       int
+	OTPMaxAttempts   int
+	OTPResendSeconds int
+
+	// ReservationMinutes is how long reserved stock is held for a checkout, ReservationSweepSeconds how often
+	// the expired reservations are released
+	ReservationMinutes      int
+	ReservationSweepSeconds int
+	// AllocationStrategy chooses the locations the reserved stock is held at, one of nearest, highest_stock or single_shipment
+	AllocationStrategy string
+}
+
+// OIDCProvider holds the client registration at an OpenID Connect provider, its endpoints are discovered from the issuer.
+// Providers of the oauth2 type, like GitHub, have neither discovery nor ID tokens, so their endpoints are configured
+// instead and the user is read from the userinfo endpoint, along with the verified email from the emails endpoint if set.
+type OIDCProvider struct {
+	Name         string
+	Type         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	EmailsURL   string
 }
 
 // AppConfig variable to hold the server config values
@@ -43,7 +128,85 @@ func LoadConfig() {
 		DBTimeout:    getEnvAsInt("DB_TIMEOUT", 2),
 		JWTSecret:    getEnv("JWT_SECRET", "someSecretKey"),
 		APIRateLimit: getEnvAsInt("API_RATE_LIMIT", 100),
+
+		JWTIssuer:   getEnv("JWT_ISSUER", "go-e-commerce"),
+		JWTAudience: getEnv("JWT_AUDIENCE", "go-e-commerce"),
+		JWTLeeway:   getEnvAsInt("JWT_LEEWAY", 30),
+
+		JWTPrivateKeyFile: getEnv("JWT_PRIVATE_KEY_FILE", ""),
+		JWTPublicKeyFiles: getEnvAsSlice("JWT_PUBLIC_KEY_FILES", nil),
+
+		AppURL:                   getEnv("APP_URL", "http://localhost:8080"),
+		MFAIssuer:                getEnv("MFA_ISSUER", "go-e-commerce"),
+		RequireEmailVerification: getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+
+		PasswordHasher:    getEnv("PASSWORD_HASHER", "argon2id"),
+		Argon2Memory:      getEnvAsInt("ARGON2_MEMORY", 64*1024),
+		Argon2Iterations:  getEnvAsInt("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism: getEnvAsInt("ARGON2_PARALLELISM", 2),
+		BcryptCost:        getEnvAsInt("BCRYPT_COST", 10),
+
+		LoginMaxFailures:    getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:  getEnvAsInt("LOGIN_IP_MAX_FAILURES", 50),
+		LoginLockoutMinutes: getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailFileDir:  getEnv("MAIL_FILE_DIR", "mails"),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		SessionSweepMinutes: getEnvAsInt("SESSION_SWEEP_MINUTES", 60),
+
+		MagicLinkRoles:   getEnvAsSlice("MAGIC_LINK_ROLES", []string{"user"}),
+		MagicLinkMinutes: getEnvAsInt("MAGIC_LINK_MINUTES", 15),
+
+		SMSDriver:        getEnv("SMS_DRIVER", "log"),
+		OTPMinutes:       getEnvAsInt("OTP_MINUTES", 5),
+		OTPMaxAttempts:   getEnvAsInt("OTP_MAX_ATTEMPTS", 5),
+		OTPResendSeconds: getEnvAsInt("OTP_RESEND_SECONDS", 60),
+
+		ReservationMinutes:      getEnvAsInt("RESERVATION_MINUTES", 15),
+		ReservationSweepSeconds: getEnvAsInt("RESERVATION_SWEEP_SECONDS", 60),
+		AllocationStrategy:      getEnv("ALLOCATION_STRATEGY", "single_shipment"),
 	}
+
+	AppConfig.OIDCProviders = getOIDCProviders(AppConfig.Domain, AppConfig.ServerPort)
+}
+
+// getOIDCProviders reads the providers listed in OIDC_PROVIDERS, each one configured by the
+// OIDC_<NAME>_TYPE, OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL
+// and OIDC_<NAME>_SCOPES environment variables. The oauth2 type providers are configured by the OIDC_<NAME>_AUTH_URL,
+// OIDC_<NAME>_TOKEN_URL, OIDC_<NAME>_USERINFO_URL and OIDC_<NAME>_EMAILS_URL ones instead of the issuer.
+func getOIDCProviders(domain, port string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range getEnvAsSlice("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		providerType := strings.ToLower(getEnv(prefix+"TYPE", "oidc"))
+		// The scopes of plain OAuth 2.0 providers are their own
+		var defaultScopes []string
+		if providerType == "oidc" {
+			defaultScopes = []string{"openid", "email", "profile"}
+		}
+
+		providers = append(providers, OIDCProvider{
+			Name:         strings.ToLower(name),
+			Type:         providerType,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", "http://"+domain+":"+port+"/api/v1/auth/oidc/"+strings.ToLower(name)+"/callback"),
+			Scopes:       getEnvAsSlice(prefix+"SCOPES", defaultScopes),
+			AuthURL:      getEnv(prefix+"AUTH_URL", ""),
+			TokenURL:     getEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL:  getEnv(prefix+"USERINFO_URL", ""),
+			EmailsURL:    getEnv(prefix+"EMAILS_URL", ""),
+		})
+	}
+	return providers
 }
 
 // getEnv reads environment variable and return default value if not found
@@ -62,3 +225,28 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsBool reads environment variable as boolean and return default value if not found
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice reads comma separated environment variable as slice and return default value if not found
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,7 @@
+ALTER TABLE "refresh_tokens"
+    ADD COLUMN "family_id" VARCHAR(64) NOT NULL DEFAULT md5(random()::text),
+    ADD COLUMN "consumed_at" TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE "refresh_tokens" ALTER COLUMN "family_id" DROP DEFAULT;
+
+CREATE INDEX "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
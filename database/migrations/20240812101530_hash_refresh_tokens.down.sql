@@ -0,0 +1,6 @@
+-- Digests can't be turned back into tokens, so the stored sessions are dropped
+DELETE FROM "refresh_tokens";
+
+ALTER TABLE "refresh_tokens" ALTER COLUMN "token_hash" TYPE VARCHAR(255);
+
+ALTER TABLE "refresh_tokens" RENAME COLUMN "token_hash" TO "token";
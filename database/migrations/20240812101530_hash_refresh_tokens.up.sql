@@ -0,0 +1,6 @@
+ALTER TABLE "refresh_tokens" RENAME COLUMN "token" TO "token_hash";
+
+-- Existing tokens keep working as their digest is what gets looked up from now on
+UPDATE "refresh_tokens" SET "token_hash" = encode(sha256("token_hash"::bytea), 'hex');
+
+ALTER TABLE "refresh_tokens" ALTER COLUMN "token_hash" TYPE CHAR(64);
@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE "email_verification_tokens" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "token_hash" CHAR(64) UNIQUE NOT NULL,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
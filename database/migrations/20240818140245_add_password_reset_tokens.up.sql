@@ -0,0 +1,12 @@
+CREATE TABLE "password_reset_tokens" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "token_hash" CHAR(64) UNIQUE NOT NULL,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
@@ -0,0 +1,42 @@
+CREATE TABLE "user_mfa" (
+    "user_id" INT PRIMARY KEY,
+    "secret" VARCHAR(64) NOT NULL,
+    "last_used_step" BIGINT NOT NULL DEFAULT 0,
+    "confirmed_at" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE TABLE "mfa_recovery_codes" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "code_hash" CHAR(64) NOT NULL,
+    "used_at" TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE,
+
+    CONSTRAINT "uq_mfa_recovery_codes_user_code"
+    UNIQUE ("user_id", "code_hash")
+);
+
+-- One row per pending two-factor login, keyed by the jti of its mfa token, so the token is single use
+-- and the guesses on it are limited
+CREATE TABLE "mfa_challenges" (
+    "id" VARCHAR(64) PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "attempts" INT NOT NULL DEFAULT 0,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
@@ -0,0 +1,22 @@
+CREATE TABLE "login_throttles" (
+    "key" VARCHAR(255) PRIMARY KEY,
+    "failed_count" INT NOT NULL DEFAULT 0,
+    "last_failed_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "locked_until" TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE "auth_events" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT,
+    "event" VARCHAR(100) NOT NULL,
+    "ip" VARCHAR(100) NOT NULL DEFAULT '',
+    "details" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_auth_events_user_id" ON "auth_events" ("user_id");
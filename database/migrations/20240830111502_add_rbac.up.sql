@@ -0,0 +1,54 @@
+CREATE TABLE "roles" (
+    "id" SERIAL PRIMARY KEY,
+    "name" VARCHAR(100) NOT NULL UNIQUE,
+    "description" VARCHAR(255) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE "permissions" (
+    "id" SERIAL PRIMARY KEY,
+    "name" VARCHAR(100) NOT NULL UNIQUE,
+    "description" VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE "role_permissions" (
+    "role_id" INT NOT NULL,
+    "permission_id" INT NOT NULL,
+
+    PRIMARY KEY ("role_id", "permission_id"),
+
+    CONSTRAINT "fk_role_id"
+    FOREIGN KEY ("role_id")
+    REFERENCES "roles" ("id")
+    ON DELETE CASCADE,
+
+    CONSTRAINT "fk_permission_id"
+    FOREIGN KEY ("permission_id")
+    REFERENCES "permissions" ("id")
+    ON DELETE CASCADE
+);
+
+INSERT INTO "roles" ("name", "description") VALUES
+    ('user', 'Shopper'),
+    ('vendor', 'Sells products'),
+    ('admin', 'Manages the platform');
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('users:read', 'View any user'),
+    ('users:write', 'Manage any user'),
+    ('roles:write', 'Change the role of users'),
+    ('products:write', 'Create and manage products');
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT r."id", p."id" FROM "roles" r, "permissions" p
+WHERE r."name" = 'admin'
+   OR (r."name" = 'vendor' AND p."name" = 'products:write');
+
+-- Keep any role already in use valid for the foreign key below
+INSERT INTO "roles" ("name") SELECT DISTINCT "role" FROM "users" ON CONFLICT ("name") DO NOTHING;
+
+ALTER TABLE "users"
+    ADD CONSTRAINT "fk_role"
+    FOREIGN KEY ("role")
+    REFERENCES "roles" ("name")
+    ON UPDATE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "suspended_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX "idx_users_created_at" ON "users" ("created_at");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_refresh_tokens_expires_at";
+
+ALTER TABLE "refresh_tokens" DROP CONSTRAINT IF EXISTS "fk_family_id";
+
+DROP TABLE IF EXISTS "sessions";
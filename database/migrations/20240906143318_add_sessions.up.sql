@@ -0,0 +1,30 @@
+CREATE TABLE "sessions" (
+    "id" VARCHAR(64) PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "user_agent" VARCHAR(512) NOT NULL DEFAULT '',
+    "ip" VARCHAR(64) NOT NULL DEFAULT '',
+    "device_label" VARCHAR(255) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "last_used_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_sessions_user_id" ON "sessions" ("user_id");
+CREATE INDEX "idx_sessions_expires_at" ON "sessions" ("expires_at");
+
+-- Every existing refresh token family becomes a session
+INSERT INTO "sessions" ("id", "user_id", "expires_at")
+SELECT "family_id", MIN("user_id"), MAX("expires_at") FROM "refresh_tokens" GROUP BY "family_id";
+
+ALTER TABLE "refresh_tokens"
+    ADD CONSTRAINT "fk_family_id"
+    FOREIGN KEY ("family_id")
+    REFERENCES "sessions" ("id")
+    ON DELETE CASCADE;
+
+CREATE INDEX "idx_refresh_tokens_expires_at" ON "refresh_tokens" ("expires_at");
@@ -0,0 +1,3 @@
+DELETE FROM "permissions" WHERE "name" IN ('profile:read', 'profile:write');
+
+DROP TABLE IF EXISTS "api_keys";
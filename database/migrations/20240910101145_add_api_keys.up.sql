@@ -0,0 +1,28 @@
+CREATE TABLE "api_keys" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "prefix" VARCHAR(16) NOT NULL,
+    "key_hash" CHAR(64) UNIQUE NOT NULL,
+    "scopes" TEXT[] NOT NULL DEFAULT '{}',
+    "expires_at" TIMESTAMP WITH TIME ZONE,
+    "last_used_at" TIMESTAMP WITH TIME ZONE,
+    "revoked_at" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_api_keys_user_id" ON "api_keys" ("user_id");
+
+-- Every role manages its own profile, API keys need these scopes to reach it
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('profile:read', 'View the own profile'),
+    ('profile:write', 'Update the own profile');
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT r."id", p."id" FROM "roles" r, "permissions" p
+WHERE p."name" IN ('profile:read', 'profile:write');
@@ -0,0 +1,8 @@
+-- The unique placeholder keeps the users without a phone valid for the constraint
+UPDATE "users" SET "phone" = 'missing-' || "id" WHERE "phone" IS NULL;
+
+ALTER TABLE "users" ALTER COLUMN "phone" SET NOT NULL;
+
+DROP TABLE IF EXISTS "oidc_states";
+
+DROP TABLE IF EXISTS "user_identities";
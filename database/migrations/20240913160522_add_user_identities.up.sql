@@ -0,0 +1,30 @@
+CREATE TABLE "user_identities" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "provider" VARCHAR(100) NOT NULL,
+    "subject" VARCHAR(255) NOT NULL,
+    "email" VARCHAR(255) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE ("provider", "subject"),
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_user_identities_user_id" ON "user_identities" ("user_id");
+
+CREATE TABLE "oidc_states" (
+    "id" SERIAL PRIMARY KEY,
+    "state_hash" CHAR(64) UNIQUE NOT NULL,
+    "provider" VARCHAR(100) NOT NULL,
+    "nonce" VARCHAR(255) NOT NULL,
+    "code_verifier" VARCHAR(255) NOT NULL,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Users provisioned from an identity provider have no phone yet
+ALTER TABLE "users" ALTER COLUMN "phone" DROP NOT NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE "magic_link_tokens" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "token_hash" CHAR(64) UNIQUE NOT NULL,
+    "nonce_hash" CHAR(64) NOT NULL,
+    "device_label" VARCHAR(255) NOT NULL DEFAULT '',
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
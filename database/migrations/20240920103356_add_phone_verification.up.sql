@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "phone_verified_at" TIMESTAMP WITH TIME ZONE;
+
+-- One pending code per user and purpose, a resend replaces it
+CREATE TABLE "phone_otps" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "purpose" VARCHAR(20) NOT NULL,
+    "phone" VARCHAR(100) NOT NULL,
+    "code_hash" CHAR(64) NOT NULL,
+    "attempts" INT NOT NULL DEFAULT 0,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE ("user_id", "purpose"),
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+-- One pending change per user, a new request replaces it
+CREATE TABLE "email_changes" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT UNIQUE NOT NULL,
+    "new_email" VARCHAR(255) NOT NULL,
+    "token_hash" CHAR(64) UNIQUE NOT NULL,
+    "cancel_token_hash" CHAR(64) UNIQUE NOT NULL,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
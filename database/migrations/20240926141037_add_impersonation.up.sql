@@ -0,0 +1,31 @@
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('users:impersonate', 'Act as any user');
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT r."id", p."id" FROM "roles" r, "permissions" p
+WHERE r."name" = 'admin' AND p."name" = 'users:impersonate';
+
+-- The requests are recorded before being served, the status is filled in once the response is written
+CREATE TABLE "impersonation_audit_log" (
+    "id" SERIAL PRIMARY KEY,
+    "actor_id" INT,
+    "user_id" INT,
+    "method" VARCHAR(10) NOT NULL,
+    "path" TEXT NOT NULL,
+    "status" INT,
+    "ip" VARCHAR(100) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_actor_id"
+    FOREIGN KEY ("actor_id")
+    REFERENCES "users" ("id")
+    ON DELETE SET NULL,
+
+    CONSTRAINT "fk_user_id"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users" ("id")
+    ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_impersonation_audit_log_actor_id" ON "impersonation_audit_log" ("actor_id");
+CREATE INDEX "idx_impersonation_audit_log_user_id" ON "impersonation_audit_log" ("user_id");
@@ -0,0 +1,8 @@
+-- The existing sessions were authenticated when they started
+ALTER TABLE "sessions" ADD COLUMN "authenticated_at" TIMESTAMP WITH TIME ZONE;
+
+UPDATE "sessions" SET "authenticated_at" = "created_at";
+
+ALTER TABLE "sessions"
+    ALTER COLUMN "authenticated_at" SET NOT NULL,
+    ALTER COLUMN "authenticated_at" SET DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,20 @@
+CREATE TABLE "products" (
+    "id" SERIAL PRIMARY KEY,
+    "vendor_id" INT NOT NULL,
+    "title" VARCHAR(255) NOT NULL,
+    "description" TEXT NOT NULL DEFAULT '',
+    "slug" VARCHAR(255) NOT NULL UNIQUE,
+    "price" BIGINT NOT NULL CHECK ("price" >= 0),
+    "currency" CHAR(3) NOT NULL,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK ("status" IN ('draft', 'active', 'archived')),
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_vendor_id"
+    FOREIGN KEY ("vendor_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_products_vendor_id" ON "products" ("vendor_id");
+CREATE INDEX "idx_products_status" ON "products" ("status");
@@ -0,0 +1,5 @@
+DELETE FROM "permissions" WHERE "name" = 'categories:write';
+
+DROP TABLE IF EXISTS "product_categories";
+
+DROP TABLE IF EXISTS "categories";
@@ -0,0 +1,47 @@
+-- The path lists the ids from the root down to the category, like "1/4/9/", so a subtree is a path prefix
+CREATE TABLE "categories" (
+    "id" SERIAL PRIMARY KEY,
+    "parent_id" INT,
+    "name" VARCHAR(255) NOT NULL,
+    "slug" VARCHAR(255) NOT NULL UNIQUE,
+    "description" TEXT NOT NULL DEFAULT '',
+    "position" INT NOT NULL DEFAULT 0,
+    "path" VARCHAR(1024) NOT NULL UNIQUE,
+    "depth" INT NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_parent_id"
+    FOREIGN KEY ("parent_id")
+    REFERENCES "categories" ("id")
+    ON DELETE RESTRICT
+);
+
+CREATE INDEX "idx_categories_parent_id" ON "categories" ("parent_id");
+CREATE INDEX "idx_categories_path" ON "categories" ("path" varchar_pattern_ops);
+
+CREATE TABLE "product_categories" (
+    "product_id" INT NOT NULL,
+    "category_id" INT NOT NULL,
+
+    PRIMARY KEY ("product_id", "category_id"),
+
+    CONSTRAINT "fk_product_id"
+    FOREIGN KEY ("product_id")
+    REFERENCES "products" ("id")
+    ON DELETE CASCADE,
+
+    CONSTRAINT "fk_category_id"
+    FOREIGN KEY ("category_id")
+    REFERENCES "categories" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_product_categories_category_id" ON "product_categories" ("category_id");
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('categories:write', 'Create and manage categories');
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT r."id", p."id" FROM "roles" r, "permissions" p
+WHERE r."name" = 'admin' AND p."name" = 'categories:write';
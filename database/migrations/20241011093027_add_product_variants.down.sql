@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "product_variant_values";
+
+DROP TABLE IF EXISTS "product_variants";
+
+DROP TABLE IF EXISTS "product_option_values";
+
+DROP TABLE IF EXISTS "product_options";
+
+ALTER TABLE "products" DROP CONSTRAINT IF EXISTS "uq_products_id_vendor_id";
@@ -0,0 +1,76 @@
+-- Lets the variants reference their product together with its vendor, so the vendor of a variant can't drift
+ALTER TABLE "products" ADD CONSTRAINT "uq_products_id_vendor_id" UNIQUE ("id", "vendor_id");
+
+CREATE TABLE "product_options" (
+    "id" SERIAL PRIMARY KEY,
+    "product_id" INT NOT NULL,
+    "name" VARCHAR(64) NOT NULL,
+    "position" INT NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "uq_product_options_product_id_name" UNIQUE ("product_id", "name"),
+
+    CONSTRAINT "fk_product_id"
+    FOREIGN KEY ("product_id")
+    REFERENCES "products" ("id")
+    ON DELETE CASCADE
+);
+
+CREATE TABLE "product_option_values" (
+    "id" SERIAL PRIMARY KEY,
+    "option_id" INT NOT NULL,
+    "value" VARCHAR(64) NOT NULL,
+    "position" INT NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "uq_product_option_values_option_id_value" UNIQUE ("option_id", "value"),
+
+    CONSTRAINT "fk_option_id"
+    FOREIGN KEY ("option_id")
+    REFERENCES "product_options" ("id")
+    ON DELETE CASCADE
+);
+
+-- The price overrides the price of the product when set, the weight is in grams.
+-- The options key lists the sorted ids of the option values, so a combination has a single variant.
+CREATE TABLE "product_variants" (
+    "id" SERIAL PRIMARY KEY,
+    "product_id" INT NOT NULL,
+    "vendor_id" INT NOT NULL,
+    "sku" VARCHAR(64) NOT NULL,
+    "price" BIGINT CHECK ("price" >= 0),
+    "barcode" VARCHAR(64),
+    "weight" INT NOT NULL DEFAULT 0 CHECK ("weight" >= 0),
+    "stock" INT NOT NULL DEFAULT 0 CHECK ("stock" >= 0),
+    "options_key" VARCHAR(255) NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "uq_product_variants_vendor_id_sku" UNIQUE ("vendor_id", "sku"),
+    CONSTRAINT "uq_product_variants_product_id_options_key" UNIQUE ("product_id", "options_key"),
+
+    CONSTRAINT "fk_product_id_vendor_id"
+    FOREIGN KEY ("product_id", "vendor_id")
+    REFERENCES "products" ("id", "vendor_id")
+    ON DELETE CASCADE
+);
+
+-- An option value in use by a variant can't be deleted
+CREATE TABLE "product_variant_values" (
+    "variant_id" INT NOT NULL,
+    "option_value_id" INT NOT NULL,
+
+    PRIMARY KEY ("variant_id", "option_value_id"),
+
+    CONSTRAINT "fk_variant_id"
+    FOREIGN KEY ("variant_id")
+    REFERENCES "product_variants" ("id")
+    ON DELETE CASCADE,
+
+    CONSTRAINT "fk_option_value_id"
+    FOREIGN KEY ("option_value_id")
+    REFERENCES "product_option_values" ("id")
+    ON DELETE RESTRICT
+);
+
+CREATE INDEX "idx_product_variant_values_option_value_id" ON "product_variant_values" ("option_value_id");
@@ -0,0 +1,13 @@
+DELETE FROM "permissions" WHERE "name" = 'inventory:reserve';
+
+ALTER TABLE "product_variants" ADD COLUMN "stock" INT NOT NULL DEFAULT 0 CHECK ("stock" >= 0);
+
+UPDATE "product_variants" v SET "stock" = l."on_hand" FROM "inventory_levels" l WHERE l."variant_id" = v."id";
+
+DROP TABLE IF EXISTS "stock_movements";
+
+DROP FUNCTION IF EXISTS "reject_stock_movement_changes";
+
+DROP TABLE IF EXISTS "stock_reservations";
+
+DROP TABLE IF EXISTS "inventory_levels";
@@ -0,0 +1,80 @@
+-- The reserved quantity is held for the checkouts in progress, the available quantity is the rest of the on hand one.
+-- The stock of a variant is written off in the ledger before the variant is deleted, its stock records and
+-- reservations keep it from being deleted meanwhile.
+CREATE TABLE "inventory_levels" (
+    "variant_id" INT PRIMARY KEY,
+    "on_hand" INT NOT NULL DEFAULT 0,
+    "reserved" INT NOT NULL DEFAULT 0,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "chk_inventory_levels_quantities" CHECK ("reserved" >= 0 AND "reserved" <= "on_hand"),
+
+    CONSTRAINT "fk_variant_id"
+    FOREIGN KEY ("variant_id")
+    REFERENCES "product_variants" ("id")
+    ON DELETE RESTRICT
+);
+
+INSERT INTO "inventory_levels" ("variant_id", "on_hand")
+SELECT "id", "stock" FROM "product_variants";
+
+ALTER TABLE "product_variants" DROP COLUMN "stock";
+
+CREATE TABLE "stock_reservations" (
+    "id" SERIAL PRIMARY KEY,
+    "variant_id" INT NOT NULL,
+    "reference" VARCHAR(255) NOT NULL,
+    "quantity" INT NOT NULL CHECK ("quantity" > 0),
+    "status" VARCHAR(20) NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'committed', 'released', 'expired')),
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "fk_variant_id"
+    FOREIGN KEY ("variant_id")
+    REFERENCES "product_variants" ("id")
+    ON DELETE RESTRICT
+);
+
+CREATE INDEX "idx_stock_reservations_reference" ON "stock_reservations" ("reference");
+CREATE INDEX "idx_stock_reservations_active_expires_at" ON "stock_reservations" ("expires_at") WHERE "status" = 'active';
+
+-- The ledger has no foreign keys, so its entries outlive the variants, reservations and users they mention
+CREATE TABLE "stock_movements" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "variant_id" INT NOT NULL,
+    "reservation_id" INT,
+    "kind" VARCHAR(20) NOT NULL CHECK ("kind" IN ('adjustment', 'reservation', 'commit', 'release', 'expiry')),
+    "on_hand_change" INT NOT NULL DEFAULT 0,
+    "reserved_change" INT NOT NULL DEFAULT 0,
+    "reason" VARCHAR(255) NOT NULL DEFAULT '',
+    "actor_id" INT,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "idx_stock_movements_variant_id" ON "stock_movements" ("variant_id", "id");
+
+CREATE FUNCTION "reject_stock_movement_changes"() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock movements are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_stock_movements_immutable"
+BEFORE UPDATE OR DELETE ON "stock_movements"
+FOR EACH ROW EXECUTE FUNCTION "reject_stock_movement_changes"();
+
+-- The row triggers don't fire on a truncate
+CREATE TRIGGER "trg_stock_movements_no_truncate"
+BEFORE TRUNCATE ON "stock_movements"
+FOR EACH STATEMENT EXECUTE FUNCTION "reject_stock_movement_changes"();
+
+INSERT INTO "stock_movements" ("variant_id", "kind", "on_hand_change", "reason")
+SELECT "variant_id", 'adjustment', "on_hand", 'initial stock' FROM "inventory_levels" WHERE "on_hand" > 0;
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('inventory:reserve', 'Reserve, commit and release stock for checkouts');
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT r."id", p."id" FROM "roles" r, "permissions" p
+WHERE r."name" = 'admin' AND p."name" = 'inventory:reserve';
@@ -0,0 +1,25 @@
+-- The transfers stay in the ledger, which can't be changed
+ALTER TABLE "stock_movements" DROP CONSTRAINT IF EXISTS "stock_movements_kind_check";
+ALTER TABLE "stock_movements" ADD CONSTRAINT "stock_movements_kind_check"
+    CHECK ("kind" IN ('adjustment', 'reservation', 'commit', 'release', 'expiry')) NOT VALID;
+ALTER TABLE "stock_movements" DROP COLUMN IF EXISTS "related_location_id";
+ALTER TABLE "stock_movements" DROP COLUMN IF EXISTS "location_id";
+
+ALTER TABLE "stock_reservations" DROP COLUMN IF EXISTS "location_id";
+
+-- The stock of the locations is added up per variant
+CREATE TEMPORARY TABLE "variant_levels" AS
+SELECT "variant_id", SUM("on_hand") AS "on_hand", SUM("reserved") AS "reserved", MAX("updated_at") AS "updated_at"
+FROM "inventory_levels" GROUP BY "variant_id";
+
+DELETE FROM "inventory_levels";
+ALTER TABLE "inventory_levels" DROP CONSTRAINT "inventory_levels_pkey";
+ALTER TABLE "inventory_levels" DROP COLUMN "location_id";
+ALTER TABLE "inventory_levels" ADD PRIMARY KEY ("variant_id");
+
+INSERT INTO "inventory_levels" ("variant_id", "on_hand", "reserved", "updated_at")
+SELECT "variant_id", "on_hand", "reserved", "updated_at" FROM "variant_levels";
+
+DROP TABLE "variant_levels";
+
+DROP TABLE IF EXISTS "locations";
@@ -0,0 +1,59 @@
+-- The warehouses the vendors ship from, the ones of higher priority are preferred
+CREATE TABLE "locations" (
+    "id" SERIAL PRIMARY KEY,
+    "vendor_id" INT NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    "address_line1" VARCHAR(255) NOT NULL DEFAULT '',
+    "address_line2" VARCHAR(255) NOT NULL DEFAULT '',
+    "city" VARCHAR(100) NOT NULL DEFAULT '',
+    "region" VARCHAR(100) NOT NULL DEFAULT '',
+    "postal_code" VARCHAR(20) NOT NULL DEFAULT '',
+    "country" CHAR(2) NOT NULL DEFAULT '',
+    "latitude" DOUBLE PRECISION,
+    "longitude" DOUBLE PRECISION,
+    "priority" INT NOT NULL DEFAULT 0,
+    "active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "uq_locations_vendor_id_name" UNIQUE ("vendor_id", "name"),
+
+    CONSTRAINT "fk_vendor_id"
+    FOREIGN KEY ("vendor_id")
+    REFERENCES "users" ("id")
+    ON DELETE CASCADE
+);
+
+-- The stock recorded so far moves to a default location of its vendor
+INSERT INTO "locations" ("vendor_id", "name")
+SELECT DISTINCT "vendor_id", 'Default' FROM "product_variants";
+
+ALTER TABLE "inventory_levels" ADD COLUMN "location_id" INT;
+
+UPDATE "inventory_levels" l SET "location_id" = loc."id"
+FROM "product_variants" v JOIN "locations" loc ON loc."vendor_id" = v."vendor_id"
+WHERE v."id" = l."variant_id";
+
+ALTER TABLE "inventory_levels" ALTER COLUMN "location_id" SET NOT NULL;
+ALTER TABLE "inventory_levels" DROP CONSTRAINT "inventory_levels_pkey";
+ALTER TABLE "inventory_levels" ADD PRIMARY KEY ("variant_id", "location_id");
+ALTER TABLE "inventory_levels" ADD CONSTRAINT "fk_location_id"
+    FOREIGN KEY ("location_id") REFERENCES "locations" ("id") ON DELETE RESTRICT;
+
+ALTER TABLE "stock_reservations" ADD COLUMN "location_id" INT;
+
+UPDATE "stock_reservations" r SET "location_id" = loc."id"
+FROM "product_variants" v JOIN "locations" loc ON loc."vendor_id" = v."vendor_id"
+WHERE v."id" = r."variant_id";
+
+ALTER TABLE "stock_reservations" ALTER COLUMN "location_id" SET NOT NULL;
+ALTER TABLE "stock_reservations" ADD CONSTRAINT "fk_location_id"
+    FOREIGN KEY ("location_id") REFERENCES "locations" ("id") ON DELETE RESTRICT;
+
+-- The entries written before the locations have none, a transfer writes an entry at each end
+-- with the other end as the related location
+ALTER TABLE "stock_movements" ADD COLUMN "location_id" INT;
+ALTER TABLE "stock_movements" ADD COLUMN "related_location_id" INT;
+ALTER TABLE "stock_movements" DROP CONSTRAINT "stock_movements_kind_check";
+ALTER TABLE "stock_movements" ADD CONSTRAINT "stock_movements_kind_check"
+    CHECK ("kind" IN ('adjustment', 'reservation', 'commit', 'release', 'expiry', 'transfer'));
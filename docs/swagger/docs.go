@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
+        "/admin/categories": {
             "post": {
-                "description": "Login a user, on success get the refreshToken and accessToken",
+                "description": "Create a category under the parent, or a root one without a parent. Without a slug one is derived from the name",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,35 +25,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "Admin"
                 ],
-                "summary": "Login user",
+                "summary": "Create Category",
                 "parameters": [
                     {
-                        "description": "Login request",
+                        "description": "Category create request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.LoginReq"
+                            "$ref": "#/definitions/category.CreateCategoryReq"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Login response",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/auth.LoginRes"
+                            "$ref": "#/definitions/category.Category"
                         }
                     },
                     "400": {
-                        "description": "Default response",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
                     },
-                    "401": {
-                        "description": "Default response",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
@@ -61,9 +67,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/refresh-token": {
-            "post": {
-                "description": "Refresh token, send the new access token based on refresh token",
+        "/admin/categories/{category_id}": {
+            "put": {
+                "description": "Update a category by provided ID in url and details in body",
                 "consumes": [
                     "application/json"
                 ],
@@ -71,35 +77,108 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "Admin"
                 ],
-                "summary": "Refresh token",
+                "summary": "Update Category",
                 "parameters": [
                     {
-                        "description": "Refresh token request",
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category update request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.RefreshTokenReq"
+                            "$ref": "#/definitions/category.UpdateCategoryReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Refresh token response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/auth.RefreshTokenRes"
+                            "$ref": "#/definitions/category.Category"
                         }
                     },
                     "400": {
-                        "description": "Default response",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
                     },
-                    "401": {
-                        "description": "Default response",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a category without subcategories, its products are only unassigned from it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete Category",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
@@ -107,9 +186,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
+        "/admin/categories/{category_id}/move": {
             "post": {
-                "description": "Register a new user with the provided details",
+                "description": "Move a category along with its subtree under another parent, or to the root without a parent",
                 "consumes": [
                     "application/json"
                 ],
@@ -117,17 +196,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "Admin"
                 ],
-                "summary": "Register a new user",
+                "summary": "Move Category",
                 "parameters": [
                     {
-                        "description": "User registration request",
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category move request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.RegisterUserReq"
+                            "$ref": "#/definitions/category.MoveCategoryReq"
                         }
                     }
                 ],
@@ -135,7 +221,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.User"
+                            "$ref": "#/definitions/category.Category"
                         }
                     },
                     "400": {
@@ -143,13 +229,25 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
                     }
                 }
             }
         },
-        "/users/{user_id}": {
-            "post": {
-                "description": "Get User Details by provided ID in url",
+        "/admin/roles": {
+            "get": {
+                "description": "List every role along with its permissions, admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -157,23 +255,124 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Admin"
                 ],
-                "summary": "Get User Details",
+                "summary": "List Roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/role.Role"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "List the users page by page, with search, filters and sorting, admin only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List Users",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": 1,
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Users per page, at most 100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search in name, email and phone",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role of the users",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "false",
+                            "true",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "false",
+                        "description": "Deleted status",
+                        "name": "deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after, RFC 3339 or YYYY-MM-DD",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or before, RFC 3339 or YYYY-MM-DD",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "name",
+                            "email",
+                            "role",
+                            "created_at",
+                            "updated_at"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort order",
+                        "name": "sort_order",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.User"
+                            "$ref": "#/definitions/user.ListUsersRes"
                         }
                     },
                     "400": {
@@ -181,13 +380,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
                     }
                 }
             }
         },
-        "/users/{user_id}/delete": {
-            "delete": {
-                "description": "Delete User Details by provided ID in url",
+        "/admin/users/{user_id}": {
+            "get": {
+                "description": "Get the details of any user by provided ID in url, including the deleted and suspended users, admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -195,9 +400,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Admin"
                 ],
-                "summary": "Delete User Details",
+                "summary": "Get Any User Details",
                 "parameters": [
                     {
                         "type": "integer",
@@ -211,7 +416,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.MessageRes"
+                            "$ref": "#/definitions/user.User"
                         }
                     },
                     "400": {
@@ -219,13 +424,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
                     }
                 }
             }
         },
-        "/users/{user_id}/password-reset": {
-            "put": {
-                "description": "Reset User Password by provided ID in url and password in body",
+        "/admin/users/{user_id}/impersonate": {
+            "post": {
+                "description": "Get a short lived access token for acting as the user, every request made with it is audited. Changing the password, deleting the account and paying aren't allowed with it",
                 "consumes": [
                     "application/json"
                 ],
@@ -233,9 +444,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Admin"
                 ],
-                "summary": "Reset User Password",
+                "summary": "Impersonate user",
                 "parameters": [
                     {
                         "type": "integer",
@@ -245,14 +456,58 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Password change request",
+                        "description": "Impersonation request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.ResetPasswordReq"
+                            "$ref": "#/definitions/auth.ImpersonateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ImpersonateRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
                         }
                     }
+                }
+            }
+        },
+        "/admin/users/{user_id}/restore": {
+            "post": {
+                "description": "Restore the soft deleted user by provided ID in url, admin only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Restore User",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -266,13 +521,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
                     }
                 }
             }
         },
-        "/users/{user_id}/update": {
+        "/admin/users/{user_id}/role": {
             "put": {
-                "description": "Update User Details by provided ID in url and details in body",
+                "description": "Change the role of the user by provided ID in url and role in body, admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -280,9 +541,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Admin"
                 ],
-                "summary": "Update User Details",
+                "summary": "Change User Role",
                 "parameters": [
                     {
                         "type": "integer",
@@ -292,12 +553,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "User Update request",
+                        "description": "Role change request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.UpdateUserReq"
+                            "$ref": "#/definitions/user.ChangeRoleReq"
                         }
                     }
                 ],
@@ -313,88 +574,5165 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.MessageRes"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.LoginReq": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
-            "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                }
-            }
-        },
-        "auth.LoginRes": {
-            "type": "object",
-            "properties": {
-                "access_token": {
-                    "type": "string"
-                },
-                "refresh_token": {
-                    "type": "string"
-                }
-            }
         },
-        "auth.RefreshTokenReq": {
-            "type": "object",
-            "required": [
+        "/admin/users/{user_id}/suspend": {
+            "post": {
+                "description": "Block the user from logging in and revoke every session of the user, admin only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Suspend user account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suspend request",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.SuspendUserReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{user_id}/unlock": {
+            "post": {
+                "description": "Clear the failed logins and the lockout of the user account, admin only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Unlock user account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{user_id}/unsuspend": {
+            "post": {
+                "description": "Lift the suspension of the user account, admin only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Unsuspend user account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Unsuspend request",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.SuspendUserReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/change-email/cancel": {
+            "post": {
+                "description": "Cancel a pending email change with the token mailed to the current email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Cancel email change",
+                "parameters": [
+                    {
+                        "description": "Cancel token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.EmailChangeTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/change-email/confirm": {
+            "post": {
+                "description": "Confirm the new email with the token mailed to it, every session of the user is revoked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Confirm email change",
+                "parameters": [
+                    {
+                        "description": "Confirmation token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.EmailChangeTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/forgot-password": {
+            "post": {
+                "description": "Send a password reset email, responds the same whether or not the email exists",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Forgot password",
+                "parameters": [
+                    {
+                        "description": "Forgot password request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ForgotPasswordReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Login a user, on success get the refreshToken and accessToken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "Login request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login/mfa": {
+            "post": {
+                "description": "Exchange the mfa token from the login along with a TOTP or recovery code, on success get the refreshToken and accessToken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete two-factor login",
+                "parameters": [
+                    {
+                        "description": "Two-factor login request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginMFAReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Logout the session, revokes the refresh token and the access tokens issued from it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout",
+                "parameters": [
+                    {
+                        "description": "Logout request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.RefreshTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/magic-link": {
+            "post": {
+                "description": "Mail a single use login link if the email belongs to an account whose role allows it, the response is the same otherwise. The link only works along with the returned nonce, also set as a cookie",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Send login link",
+                "parameters": [
+                    {
+                        "description": "Login link request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.MagicLinkReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login link response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.MagicLinkRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/magic-link/consume": {
+            "post": {
+                "description": "Log in with the token of a mailed login link, on the device which asked for it, on success get the refreshToken and accessToken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log in with login link",
+                "parameters": [
+                    {
+                        "description": "Login link token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ConsumeMagicLinkReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "Verify the OpenID Connect provider response and log in the linked user, the user with the same verified email or a new user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete social login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Redirect the browser to the OpenID Connect or OAuth 2.0 provider, which redirects back to the callback",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Start social login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "500": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "502": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/phone/login": {
+            "post": {
+                "description": "Login with a verified phone and the texted login code, on success get the refreshToken and accessToken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Login with phone",
+                "parameters": [
+                    {
+                        "description": "Phone login request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.PhoneLoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/phone/otp": {
+            "post": {
+                "description": "Text a login code if the phone belongs to an account which verified it, responds the same whether or not the phone exists",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Send phone login code",
+                "parameters": [
+                    {
+                        "description": "Phone login code request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.PhoneOTPReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/reauthenticate": {
+            "post": {
+                "description": "Prove the credentials again with the password or a TOTP or recovery code, for an access token allowing the sensitive operations like deleting the account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Reauthenticate",
+                "parameters": [
+                    {
+                        "description": "Reauthenticate request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ReauthenticateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ReauthenticateRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh-token": {
+            "post": {
+                "description": "Refresh token, send the new access token based on refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh token",
+                "parameters": [
+                    {
+                        "description": "Refresh token request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.RefreshTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Refresh token response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.RefreshTokenRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Register a new user with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.RegisterUserReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/resend-verification": {
+            "post": {
+                "description": "Send a new verification email, responds the same whether or not the email exists",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Resend verification email",
+                "parameters": [
+                    {
+                        "description": "Resend verification request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ResendVerificationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/reset-password": {
+            "post": {
+                "description": "Set a new password with the token from the password reset email, revokes all the sessions of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset password request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ResetPasswordReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Verify the user email with the token sent on registration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Verify email",
+                "parameters": [
+                    {
+                        "description": "Verify email request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.VerifyEmailReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories": {
+            "get": {
+                "description": "Get the whole category tree, or the subtree of the root, with the children nested",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get Category Tree",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Root of the subtree",
+                        "name": "root_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/category.Category"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{category_id}": {
+            "get": {
+                "description": "Get a category by provided ID in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get Category",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/category.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{category_id}/breadcrumbs": {
+            "get": {
+                "description": "Get the ancestors of a category from the root down, the category included",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get Category Breadcrumbs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/category.Category"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/inventory/reservations": {
+            "post": {
+                "description": "Hold the stock of the items of a checkout until it's committed, released or expires, all the items or none, at the locations chosen by the allocation strategy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "Reserve Stock",
+                "parameters": [
+                    {
+                        "description": "Stock reservation request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.ReserveReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.ReservationsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/inventory/reservations/{reference}/commit": {
+            "post": {
+                "description": "Take the stock held for a checkout off the on hand stock once the order is placed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "Commit Reservations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Checkout reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.ReservationsRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/inventory/reservations/{reference}/release": {
+            "post": {
+                "description": "Give the stock held for a checkout back, when it's abandoned or the payment fails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "Release Reservations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Checkout reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.ReservationsRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "List the active products page by page, with search, filters and sorting",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "List Products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Products per page, at most 100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search in title and description",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Vendor of the products",
+                        "name": "vendor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Lowest price, in minor units",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Highest price, in minor units",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency code",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Category of the products, its descendants included",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "title",
+                            "price",
+                            "created_at",
+                            "updated_at"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort order",
+                        "name": "sort_order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.ListProductsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/slug/{slug}": {
+            "get": {
+                "description": "Get an active product by provided slug in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Get Product By Slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.Product"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{product_id}": {
+            "get": {
+                "description": "Get an active product by provided ID in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Get Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{product_id}/variants": {
+            "get": {
+                "description": "Get the options and the variants of an active product by provided ID in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Get Product Variants",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/variant.ProductVariantsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}": {
+            "post": {
+                "description": "Get User Details by provided ID in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get User Details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/api-keys": {
+            "get": {
+                "description": "List the API keys of the user, with their prefix, scopes, expiry and last use",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "List API Keys",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apikey.APIKey"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a personal API key for the user, sent in the X-API-Key header. The key is only shown in this response, needs a login or re-authentication within the last 5 minutes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Create API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API key request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apikey.CreateAPIKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/apikey.CreateAPIKeyRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/api-keys/{api_key_id}": {
+            "delete": {
+                "description": "Revoke an API key of the user, it stops working right away",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Revoke API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "api_key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/change-email": {
+            "post": {
+                "description": "Start changing the email of the user, needs the password and a login or re-authentication within the last 5 minutes. A confirmation link is mailed to the new email and a cancel link to the current one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Change email",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Change email request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ChangeEmailReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/delete": {
+            "delete": {
+                "description": "Delete User Details by provided ID in url, needs a login or re-authentication within the last 5 minutes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete User Details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/logout-all": {
+            "post": {
+                "description": "Logout the user everywhere, revokes all the refresh tokens of the user and the access tokens issued from them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout from all sessions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/mfa/confirm": {
+            "post": {
+                "description": "Enable two-factor authentication with a first TOTP code, responds with the one time recovery codes, needs a login or re-authentication within the last 5 minutes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Confirm two-factor enrollment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Confirm two-factor request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ConfirmMFAReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ConfirmMFARes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/mfa/disable": {
+            "post": {
+                "description": "Disable two-factor authentication, needs the password and a TOTP or recovery code, needs a login or re-authentication within the last 5 minutes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Disable two-factor request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.DisableMFAReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/mfa/enroll": {
+            "post": {
+                "description": "Create a new TOTP secret for the user, it's enabled once confirmed with a first code, needs a login or re-authentication within the last 5 minutes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Start two-factor enrollment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.EnrollMFARes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/password-reset": {
+            "put": {
+                "description": "Reset User Password by provided ID in url and password in body, needs a login or re-authentication within the last 5 minutes, every session of the user is ended",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Reset User Password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password change request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ResetPasswordReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/phone/send-verification": {
+            "post": {
+                "description": "Text a code for verifying the phone of the user, or a new phone which replaces it once verified. Another code can be asked for once the resend cooldown is over",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Send phone verification code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Send phone verification request",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.SendPhoneVerificationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "429": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/phone/verify": {
+            "post": {
+                "description": "Verify the phone the code was texted to and set it as the phone of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Verify phone",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Verify phone request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.VerifyPhoneReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/sessions": {
+            "get": {
+                "description": "List the active sessions of the user with their device, the one of the request is flagged as current",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "List sessions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.Session"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/sessions/{session_id}": {
+            "delete": {
+                "description": "End a session of the user, its refresh and access tokens stop working",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Revoke session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Default response",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/update": {
+            "put": {
+                "description": "Update User Details by provided ID in url and details in body",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update User Details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User Update request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/inventory/{variant_id}": {
+            "get": {
+                "description": "Get the on hand, reserved and available stock of a variant of the vendor at each of its locations, along with the totals",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Get Variant Stock",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.Stock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/inventory/{variant_id}/adjust": {
+            "post": {
+                "description": "Add to the on hand stock of a variant of the vendor at a location, or remove from it with a negative quantity, recording the reason in the ledger",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Adjust Variant Stock",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock adjustment request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.AdjustStockReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.Level"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/inventory/{variant_id}/movements": {
+            "get": {
+                "description": "List the stock ledger of a variant of the vendor page by page, the latest entries first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "List Stock Movements",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Entries per page, at most 100",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.ListMovementsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/inventory/{variant_id}/transfer": {
+            "post": {
+                "description": "Move available stock of a variant of the vendor between two of its locations, recording both ends in the ledger",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Transfer Variant Stock",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock transfer request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.TransferStockReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/inventory.TransferStockRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/locations": {
+            "get": {
+                "description": "List the warehouses of the vendor, the preferred ones first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "List Locations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/location.Location"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a warehouse of the vendor with its address and priority",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Create Location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Location create request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/location.CreateLocationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/location.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/locations/{location_id}": {
+            "get": {
+                "description": "Get a warehouse of the vendor by provided ID in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Get Location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "location_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/location.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update a warehouse of the vendor by provided ID in url and details in body, an inactive one isn't shipped from",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Update Location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "location_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Location update request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/location.UpdateLocationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/location.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a warehouse of the vendor which never had stock, the others can be deactivated instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Delete Location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "location_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products": {
+            "get": {
+                "description": "List the products of the vendor in any status page by page, with search, filters and sorting",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "List Vendor Products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Products per page, at most 100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search in title and description",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "draft",
+                            "active",
+                            "archived"
+                        ],
+                        "type": "string",
+                        "description": "Status of the products",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Lowest price, in minor units",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Highest price, in minor units",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency code",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Category of the products, its descendants included",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "title",
+                            "price",
+                            "created_at",
+                            "updated_at"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort order",
+                        "name": "sort_order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.ListProductsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a product of the vendor, a draft unless the status is active. Without a slug one is derived from the title",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Create Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product create request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/product.CreateProductReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/product.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}": {
+            "get": {
+                "description": "Get a product of the vendor in any status by provided ID in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Get Vendor Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update a product of the vendor by provided ID in url and details in body",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Update Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product update request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/product.UpdateProductReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/archive": {
+            "post": {
+                "description": "Archive a product of the vendor, hiding it from the shoppers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Archive Product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/categories": {
+            "put": {
+                "description": "Replace the categories of a product of the vendor, an empty list unassigns it from every category",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Set Product Categories",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product categories request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/product.SetProductCategoriesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/product.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/options": {
+            "post": {
+                "description": "Add an option with its values to a product of the vendor, before the product has variants",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Create Product Option",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Option create request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/variant.CreateOptionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/variant.Option"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/options/{option_id}": {
+            "delete": {
+                "description": "Delete an option of a product of the vendor along with its values, unless a variant has one of them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Delete Product Option",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option ID",
+                        "name": "option_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/options/{option_id}/values": {
+            "post": {
+                "description": "Add a value to an option of a product of the vendor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Add Product Option Value",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option ID",
+                        "name": "option_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Option value request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/variant.AddOptionValueReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/variant.OptionValue"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/options/{option_id}/values/{value_id}": {
+            "delete": {
+                "description": "Delete a value of an option of a product of the vendor, unless a variant has it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Delete Product Option Value",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option ID",
+                        "name": "option_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Option value ID",
+                        "name": "value_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/variants": {
+            "get": {
+                "description": "Get the options and the variants of a product of the vendor in any status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Get Vendor Product Variants",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/variant.ProductVariantsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a variant with a single value of every option to a product of the vendor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Create Product Variant",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variant create request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/variant.CreateVariantReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/variant.Variant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/variants/generate": {
+            "post": {
+                "description": "Create a variant of every combination of the option values a product of the vendor doesn't have yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Generate Product Variants",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variant generate request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/variant.GenerateVariantsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/variant.Variant"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{user_id}/products/{product_id}/variants/{variant_id}": {
+            "put": {
+                "description": "Update a variant of a product of the vendor by provided ID in url and details in body",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Update Product Variant",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variant update request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/variant.UpdateVariantReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/variant.Variant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a variant of a product of the vendor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vendor"
+                ],
+                "summary": "Delete Product Variant",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Vendor user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MessageRes"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apikey.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apikey.CreateAPIKeyReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apikey.CreateAPIKeyRes": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/apikey.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ChangeEmailReq": {
+            "type": "object",
+            "required": [
+                "new_email",
+                "password"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "new_email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ConfirmMFAReq": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.ConfirmMFARes": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "auth.ConsumeMagicLinkReq": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "nonce": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.DisableMFAReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.EmailChangeTokenReq": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.EnrollMFARes": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ForgotPasswordReq": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ImpersonateReq": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "auth.ImpersonateRes": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginMFAReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginReq": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginRes": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "mfa_required": {
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MagicLinkReq": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MagicLinkRes": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "auth.PhoneLoginReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "phone"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "device_label": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.PhoneOTPReq": {
+            "type": "object",
+            "required": [
+                "phone"
+            ],
+            "properties": {
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ReauthenticateReq": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ReauthenticateRes": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.RefreshTokenReq": {
+            "type": "object",
+            "required": [
                 "refresh_token"
             ],
             "properties": {
-                "refresh_token": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.RefreshTokenRes": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.RegisterUserReq": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password",
+                "phone"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ResendVerificationReq": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ResetPasswordReq": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.SendPhoneVerificationReq": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "device_label": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.SuspendUserReq": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "auth.VerifyEmailReq": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.VerifyPhoneReq": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "category.Category": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/category.Category"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "category.CreateCategoryReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "category.MoveCategoryReq": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "parent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "category.UpdateCategoryReq": {
+            "type": "object",
+            "required": [
+                "id",
+                "name",
+                "slug"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "inventory.AdjustStockReq": {
+            "type": "object",
+            "required": [
+                "location_id",
+                "quantity",
+                "reason",
+                "variant_id"
+            ],
+            "properties": {
+                "location_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "variant_id": {
+                    "type": "integer"
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.Coordinates": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180
+                }
+            }
+        },
+        "inventory.Level": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer"
+                },
+                "location_id": {
+                    "type": "integer"
+                },
+                "on_hand": {
+                    "type": "integer"
+                },
+                "reserved": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.ListMovementsRes": {
+            "type": "object",
+            "properties": {
+                "movements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/inventory.Movement"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.Movement": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "integer"
+                },
+                "on_hand_change": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "related_location_id": {
+                    "type": "integer"
+                },
+                "reservation_id": {
+                    "type": "integer"
+                },
+                "reserved_change": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.Reservation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.ReservationsRes": {
+            "type": "object",
+            "properties": {
+                "reference": {
+                    "type": "string"
+                },
+                "reservations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/inventory.Reservation"
+                    }
+                }
+            }
+        },
+        "inventory.ReserveItem": {
+            "type": "object",
+            "required": [
+                "variant_id"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.ReserveReq": {
+            "type": "object",
+            "required": [
+                "items",
+                "reference"
+            ],
+            "properties": {
+                "destination": {
+                    "$ref": "#/definitions/inventory.Coordinates"
+                },
+                "items": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/inventory.ReserveItem"
+                    }
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "nearest",
+                        "highest_stock",
+                        "single_shipment"
+                    ]
+                }
+            }
+        },
+        "inventory.Stock": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer"
+                },
+                "levels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/inventory.Level"
+                    }
+                },
+                "on_hand": {
+                    "type": "integer"
+                },
+                "reserved": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.TransferStockReq": {
+            "type": "object",
+            "required": [
+                "from_location_id",
+                "to_location_id",
+                "variant_id"
+            ],
+            "properties": {
+                "from_location_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "to_location_id": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer"
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "inventory.TransferStockRes": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "$ref": "#/definitions/inventory.Level"
+                },
+                "to": {
+                    "$ref": "#/definitions/inventory.Level"
+                }
+            }
+        },
+        "location.CreateLocationReq": {
+            "type": "object",
+            "required": [
+                "address_line1",
+                "city",
+                "country",
+                "name"
+            ],
+            "properties": {
+                "address_line1": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "address_line2": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "location.Location": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "address_line1": {
+                    "type": "string"
+                },
+                "address_line2": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "location.UpdateLocationReq": {
+            "type": "object",
+            "required": [
+                "address_line1",
+                "city",
+                "country",
+                "id",
+                "name"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "address_line1": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "address_line2": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "product.CreateProductReq": {
+            "type": "object",
+            "required": [
+                "currency",
+                "title"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "active"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "product.ListProductsRes": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/product.Product"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "product.Product": {
+            "type": "object",
+            "properties": {
+                "category_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
                     "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "vendor_id": {
+                    "type": "integer"
                 }
             }
         },
-        "auth.RefreshTokenRes": {
+        "product.SetProductCategoriesReq": {
             "type": "object",
+            "required": [
+                "category_ids",
+                "id"
+            ],
             "properties": {
-                "access_token": {
-                    "type": "string"
+                "category_ids": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "vendor_id": {
+                    "type": "integer"
                 }
             }
         },
-        "auth.RegisterUserReq": {
+        "product.UpdateProductReq": {
             "type": "object",
             "required": [
-                "email",
-                "name",
-                "password",
-                "phone",
-                "role"
+                "currency",
+                "id",
+                "slug",
+                "status",
+                "title"
             ],
             "properties": {
-                "email": {
+                "currency": {
                     "type": "string"
                 },
-                "name": {
+                "description": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 3
+                    "maxLength": 10000
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "id": {
+                    "type": "integer"
                 },
-                "phone": {
-                    "type": "string"
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
                 },
-                "role": {
+                "slug": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "status": {
                     "type": "string",
                     "enum": [
-                        "user",
-                        "vendor"
+                        "draft",
+                        "active",
+                        "archived"
                     ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "role.Role": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "user.ChangeRoleReq": {
+            "type": "object",
+            "required": [
+                "id",
+                "role"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.ListUsersRes": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.User"
+                    }
                 }
             }
         },
@@ -422,9 +5760,7 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "id",
-                "name",
-                "phone",
-                "role"
+                "name"
             ],
             "properties": {
                 "id": {
@@ -434,16 +5770,6 @@ const docTemplate = `{
                     "type": "string",
                     "maxLength": 100,
                     "minLength": 3
-                },
-                "phone": {
-                    "type": "string"
-                },
-                "role": {
-                    "type": "string",
-                    "enum": [
-                        "user",
-                        "vendor"
-                    ]
                 }
             }
         },
@@ -456,9 +5782,15 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "email_verified_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "is_deleted": {
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -468,9 +5800,15 @@ const docTemplate = `{
                 "phone": {
                     "type": "string"
                 },
+                "phone_verified_at": {
+                    "type": "string"
+                },
                 "role": {
                     "type": "string"
                 },
+                "suspended_at": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -486,6 +5824,274 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "variant.AddOptionValueReq": {
+            "type": "object",
+            "required": [
+                "option_id",
+                "product_id",
+                "value"
+            ],
+            "properties": {
+                "option_id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "variant.CreateOptionReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "product_id",
+                "values"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "values": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "vendor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "variant.CreateVariantReq": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "sku"
+            ],
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "option_value_ids": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "vendor_id": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "variant.GenerateVariantsReq": {
+            "type": "object",
+            "required": [
+                "product_id"
+            ],
+            "properties": {
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "sku_prefix": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "vendor_id": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "variant.Option": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/variant.OptionValue"
+                    }
+                }
+            }
+        },
+        "variant.OptionValue": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "option_id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "variant.ProductVariantsRes": {
+            "type": "object",
+            "properties": {
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/variant.Option"
+                    }
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/variant.Variant"
+                    }
+                }
+            }
+        },
+        "variant.UpdateVariantReq": {
+            "type": "object",
+            "required": [
+                "id",
+                "product_id",
+                "sku"
+            ],
+            "properties": {
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "vendor_id": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "variant.Variant": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "option_value_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "vendor_id": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,34 @@
+package apikey
+
+import "time"
+
+// APIKey represents a personal API key of a user, for calling the API from other systems.
+// Only the SHA-256 digest of the key is stored, the prefix is kept visible to tell the keys apart.
+// The scopes are the permissions granted to the key, limited to the ones of the user's role.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyReq represents the request payload for creating an API key.
+// Without an expiry the key is valid until revoked.
+type CreateAPIKeyReq struct {
+	UserID        int64    `json:"user_id"`
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=365"`
+}
+
+// CreateAPIKeyRes represents the response returned upon API key creation, the key is never shown again.
+type CreateAPIKeyRes struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
@@ -0,0 +1,130 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler struct to hold the API key service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the API key Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// CreateAPIKey  godoc
+// @Summary      Create API Key
+// @Description  Create a personal API key for the user, sent in the X-API-Key header. The key is only shown in this response, needs a login or re-authentication within the last 5 minutes
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  CreateAPIKeyReq  true  "API key request"
+// @Success      201  {object}  CreateAPIKeyRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /users/{user_id}/api-keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var createAPIKeyReq CreateAPIKeyReq
+	if err := utils.ReadFromRequest(r, &createAPIKeyReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	createAPIKeyReq.UserID = int64(userID)
+
+	if err := utils.Validate.Struct(createAPIKeyReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CreateAPIKey(r.Context(), &createAPIKeyReq)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// ListAPIKeys   godoc
+// @Summary      List API Keys
+// @Description  List the API keys of the user, with their prefix, scopes, expiry and last use
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {array}   APIKey
+// @Failure      400  {object}  utils.MessageRes
+// @Router       /users/{user_id}/api-keys [get]
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// RevokeAPIKey  godoc
+// @Summary      Revoke API Key
+// @Description  Revoke an API key of the user, it stops working right away
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        id          path  int  true  "User ID"
+// @Param        api_key_id  path  int  true  "API Key ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /users/{user_id}/api-keys/{api_key_id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	apiKeyIDstr := chi.URLParam(r, "api_key_id")
+	apiKeyID, err := strconv.Atoi(apiKeyIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.RevokeAPIKey(r.Context(), userID, apiKeyID)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
@@ -0,0 +1,137 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Repository interface for the API key repository
+type Repository interface {
+	// Create stores a new API key and returns the created key.
+	Create(ctx context.Context, apiKey *APIKey) (*APIKey, error)
+
+	// GetByUserID returns every API key of the user, the newest first.
+	GetByUserID(ctx context.Context, userID int) ([]*APIKey, error)
+
+	// GetByKeyHash find and returns the API key by the digest of the key.
+	GetByKeyHash(ctx context.Context, keyHash string) (*APIKey, error)
+
+	// Revoke revokes the API key of the user, returns false if no such active key exists.
+	Revoke(ctx context.Context, userID int, apiKeyID int) (bool, error)
+
+	// MarkUsed records the use of the API key, at most once a minute.
+	MarkUsed(ctx context.Context, apiKeyID int) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, apiKey *APIKey) (*APIKey, error) {
+	insertQuery := `INSERT INTO api_keys(user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		apiKey.UserID,
+		apiKey.Name,
+		apiKey.Prefix,
+		apiKey.KeyHash,
+		pq.Array(apiKey.Scopes),
+		apiKey.ExpiresAt,
+	).Scan(&apiKey.ID, &apiKey.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *repository) GetByUserID(ctx context.Context, userID int) ([]*APIKey, error) {
+	selectQuery := `SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys
+		WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []*APIKey{}
+	for rows.Next() {
+		var apiKey APIKey
+		err := rows.Scan(
+			&apiKey.ID,
+			&apiKey.UserID,
+			&apiKey.Name,
+			&apiKey.Prefix,
+			pq.Array(&apiKey.Scopes),
+			&apiKey.ExpiresAt,
+			&apiKey.LastUsedAt,
+			&apiKey.RevokedAt,
+			&apiKey.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, &apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+func (r *repository) GetByKeyHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	var apiKey APIKey
+	selectQueryByKeyHash := `SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys WHERE key_hash = $1`
+
+	err := r.db.QueryRowContext(ctx, selectQueryByKeyHash, keyHash).Scan(
+		&apiKey.ID,
+		&apiKey.UserID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		&apiKey.KeyHash,
+		pq.Array(&apiKey.Scopes),
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+		&apiKey.RevokedAt,
+		&apiKey.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *repository) Revoke(ctx context.Context, userID int, apiKeyID int) (bool, error) {
+	revokeQuery := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, revokeQuery, apiKeyID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) MarkUsed(ctx context.Context, apiKeyID int) error {
+	// Writing on every request would be wasteful, a minute is precise enough
+	markUsedQuery := `UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`
+
+	_, err := r.db.ExecContext(ctx, markUsedQuery, apiKeyID)
+
+	return err
+}
@@ -0,0 +1,195 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/internal/role"
+	"github.com/aslam-ep/go-e-commerce/internal/user"
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Service interface for the API key service
+type Service interface {
+	// CreateAPIKey Creates a new API key for the user and returns it, the key itself is only returned here.
+	CreateAPIKey(c context.Context, req *CreateAPIKeyReq) (*CreateAPIKeyRes, error)
+
+	// ListAPIKeys Retrieves every API key of the user, including the revoked and expired ones.
+	ListAPIKeys(c context.Context, userID int) ([]*APIKey, error)
+
+	// RevokeAPIKey Revokes an API key of the user and returns a message indicating success or failure.
+	RevokeAPIKey(c context.Context, userID int, apiKeyID int) (*utils.MessageRes, error)
+
+	// Authenticate Resolves an API key into the claims of its user, with the permissions limited to the key scopes.
+	Authenticate(c context.Context, key string) (*utils.Claims, error)
+}
+
+// ErrInvalidAPIKey is returned when the API key is unknown, revoked, expired or its user can't log in.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ErrAPIKeyNotFound is returned when the API key doesn't exist, is already revoked or belongs to another user.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const (
+	// keyPrefix marks the keys issued by the API, so leaked keys are easy to spot
+	keyPrefix = "gec_"
+	// visiblePrefixLength is the number of leading characters of a key kept in clear
+	visiblePrefixLength = len(keyPrefix) + 8
+)
+
+type service struct {
+	apiKeyRepo Repository
+	userRepo   user.Repository
+	roleRepo   role.Repository
+	timeout    time.Duration
+}
+
+// NewService initialize and return the Service
+func NewService(akr Repository, ur user.Repository, rr role.Repository) Service {
+	return &service{
+		apiKeyRepo: akr,
+		userRepo:   ur,
+		roleRepo:   rr,
+		timeout:    time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) CreateAPIKey(c context.Context, req *CreateAPIKeyReq) (*CreateAPIKeyRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	u, err := s.userRepo.GetByID(ctx, int(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	userRole, err := s.roleRepo.GetByName(ctx, u.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	// A key can't be granted more than the user's role allows
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(userRole.Permissions, scope) {
+			return nil, fmt.Errorf("scope %q is not granted to the user's role", scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret, err := utils.GenerateRandomString(24)
+	if err != nil {
+		return nil, err
+	}
+	key := keyPrefix + secret
+
+	apiKey := &APIKey{
+		UserID:  u.ID,
+		Name:    req.Name,
+		Prefix:  key[:visiblePrefixLength],
+		KeyHash: utils.HashToken(key),
+		Scopes:  scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	createdAPIKey, err := s.apiKeyRepo.Create(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CreateAPIKeyRes{
+		APIKey: createdAPIKey,
+		Key:    key,
+	}
+
+	return res, nil
+}
+
+func (s *service) ListAPIKeys(c context.Context, userID int) ([]*APIKey, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.apiKeyRepo.GetByUserID(ctx, userID)
+}
+
+func (s *service) RevokeAPIKey(c context.Context, userID int, apiKeyID int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	revoked, err := s.apiKeyRepo.Revoke(ctx, userID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "API key revoked.",
+	}
+
+	return res, nil
+}
+
+func (s *service) Authenticate(c context.Context, key string) (*utils.Claims, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	apiKey, err := s.apiKeyRepo.GetByKeyHash(ctx, utils.HashToken(key))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Deleted and suspended users lose the access of their keys too
+	u, err := s.userRepo.GetByID(ctx, int(apiKey.UserID))
+	if err != nil || u.SuspendedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// The role may have changed since the key was created
+	userRole, err := s.roleRepo.GetByName(ctx, u.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := []string{}
+	for _, scope := range apiKey.Scopes {
+		if slices.Contains(userRole.Permissions, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if err := s.apiKeyRepo.MarkUsed(ctx, int(apiKey.ID)); err != nil {
+		log.Printf("Failed to record the use of api key %d: %v", apiKey.ID, err)
+	}
+
+	claims := &utils.Claims{
+		Role:        u.Role,
+		Permissions: permissions,
+		Type:        utils.APIKeyType,
+		StandardClaims: jwt.StandardClaims{
+			Id:      apiKey.Prefix,
+			Subject: strconv.FormatInt(u.ID, 10),
+		},
+	}
+
+	return claims, nil
+}
@@ -7,28 +7,62 @@ type RegisterUserReq struct {
 	Name     string `json:"name" validate:"required,min=3,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Phone    string `json:"phone" validate:"required,e164"`
-	Role     string `json:"role" validate:"required,oneof=user vendor"`
 	Password string `json:"password" validate:"required,min=6"`
 }
 
 // RefreshToken represents a refresh token issued to a user for renewing access tokens.
+// Tokens rotated from the same login share a FamilyID, and a rotated token keeps its row with ConsumedAt set.
+// Only the SHA-256 digest of the token is stored.
 type RefreshToken struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	FamilyID   string     `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+}
+
+// Session represents a login of a user on a device, every refresh token rotated from the login belongs to it.
+// The session id is the refresh token family id, which is also the session_id claim of its access tokens.
+type Session struct {
+	ID          string    `json:"id"`
+	UserID      int64     `json:"user_id"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `json:"ip"`
+	DeviceLabel string    `json:"device_label"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
 }
 
 // LoginReq represents the request payload for user login.
+// Without a device label one is derived from the user agent.
 type LoginReq struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password" validate:"required"`
+	DeviceLabel string `json:"device_label" validate:"max=255"`
+	IP          string `json:"-"`
+	UserAgent   string `json:"-"`
 }
 
 // LoginRes represents the response returned upon successful user login.
+// Users with two-factor authentication get only an MFAToken, to exchange along with a code at /auth/login/mfa.
 type LoginRes struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+// LoginMFAReq represents the request payload for completing a login with a second factor.
+// The code is either a TOTP code or an unused recovery code.
+type LoginMFAReq struct {
+	MFAToken    string `json:"mfa_token" validate:"required"`
+	Code        string `json:"code" validate:"required"`
+	DeviceLabel string `json:"device_label" validate:"max=255"`
+	IP          string `json:"-"`
+	UserAgent   string `json:"-"`
 }
 
 // RefreshTokenReq represents the request payload for refreshing an access token.
@@ -38,5 +72,297 @@ type RefreshTokenReq struct {
 
 // RefreshTokenRes represents the response returned upon successful token refresh.
 type RefreshTokenRes struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// EmailVerification represents a single use token sent to a user for verifying their email.
+// Only the SHA-256 digest of the token is stored.
+type EmailVerification struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VerifyEmailReq represents the request payload for verifying a user's email.
+type VerifyEmailReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationReq represents the request payload for sending a new email verification token.
+type ResendVerificationReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// PasswordReset represents a single use token sent to a user for resetting a forgotten password.
+// Only the SHA-256 digest of the token is stored.
+type PasswordReset struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ForgotPasswordReq represents the request payload for requesting a password reset email.
+type ForgotPasswordReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordReq represents the request payload for setting a new password with a reset token.
+type ResetPasswordReq struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// MFA represents the TOTP second factor of a user, it's enabled once confirmed with a first code.
+type MFA struct {
+	UserID       int64      `json:"user_id"`
+	Secret       string     `json:"-"`
+	LastUsedStep int64      `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// EnrollMFARes represents the response returned upon starting the two-factor enrollment.
+type EnrollMFARes struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// ConfirmMFAReq represents the request payload for enabling two-factor authentication with a first code.
+type ConfirmMFAReq struct {
+	ID   int64  `json:"id"`
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// ConfirmMFARes represents the response returned upon enabling two-factor authentication.
+// The recovery codes are shown only once.
+type ConfirmMFARes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// DisableMFAReq represents the request payload for disabling two-factor authentication.
+// The code is either a TOTP code or an unused recovery code.
+type DisableMFAReq struct {
+	ID       int64  `json:"id"`
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+	IP       string `json:"-"`
+}
+
+// LoginThrottle represents the failed login attempts for an account or an IP address.
+type LoginThrottle struct {
+	Key          string     `json:"key"`
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+// AuthEvent represents a recorded security event, like an account lockout.
+type AuthEvent struct {
+	ID        int64     `json:"id"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	Event     string    `json:"event"`
+	IP        string    `json:"ip"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UnlockUserReq represents the request payload for unlocking a locked user account.
+type UnlockUserReq struct {
+	ID      int64 `json:"id"`
+	AdminID int64 `json:"-"`
+}
+
+// SuspendUserReq represents the request payload for suspending a user account.
+type SuspendUserReq struct {
+	ID      int64  `json:"id"`
+	AdminID int64  `json:"-"`
+	Reason  string `json:"reason" validate:"max=255"`
+}
+
+// OIDCState represents a pending OpenID Connect login, from the redirect to the provider until its callback.
+// Only the SHA-256 digest of the state is stored, the state itself is also kept in a cookie of the browser.
+type OIDCState struct {
+	ID           int64     `json:"id"`
+	StateHash    string    `json:"-"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// UserIdentity represents the account of a user at an OpenID Connect provider, identified by its subject.
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCLoginRes represents the start of an OpenID Connect login, the browser is redirected to AuthURL.
+type OIDCLoginRes struct {
+	AuthURL string `json:"auth_url"`
+	State   string `json:"-"`
+}
+
+// OIDCCallbackReq represents the provider response of an OpenID Connect login.
+// BrowserState is the state kept in the cookie, it must match the returned one.
+type OIDCCallbackReq struct {
+	Provider     string `json:"-"`
+	Code         string `json:"code" validate:"required"`
+	State        string `json:"state" validate:"required"`
+	BrowserState string `json:"-"`
+	IP           string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+// MFAChallenge represents a pending two-factor login, keyed by the jti of its mfa token.
+// It's removed once the login completes, and counts the guesses made on it.
+type MFAChallenge struct {
+	ID        string    `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MagicLink represents a single use login link mailed to a user, bound to the device which asked for it.
+// Only the SHA-256 digests of the token and of the device nonce are stored.
+type MagicLink struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	TokenHash   string    `json:"-"`
+	NonceHash   string    `json:"-"`
+	DeviceLabel string    `json:"device_label"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// MagicLinkReq represents the request payload for mailing a login link.
+type MagicLinkReq struct {
+	Email       string `json:"email" validate:"required,email"`
+	DeviceLabel string `json:"device_label" validate:"max=255"`
+}
+
+// MagicLinkRes represents the response to a login link request.
+// The nonce is also set as a cookie, the link only works along with it.
+type MagicLinkRes struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Nonce   string `json:"nonce"`
+}
+
+// ConsumeMagicLinkReq represents the request payload for logging in with a mailed link.
+// Without a nonce in the body the one of the cookie is used.
+type ConsumeMagicLinkReq struct {
+	Token     string `json:"token" validate:"required"`
+	Nonce     string `json:"nonce"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// PhoneOTP represents a one time code texted to a user, for verifying their phone or logging in.
+// Only the SHA-256 digest of the code is stored, along with the wrong guesses made.
+type PhoneOTP struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Purpose   string    `json:"purpose"`
+	Phone     string    `json:"phone"`
+	CodeHash  string    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SendPhoneVerificationReq represents the request payload for texting a code for verifying a phone of a user.
+// With a new phone the code is texted to it, and the phone replaces the current one once verified.
+type SendPhoneVerificationReq struct {
+	ID    int64  `json:"id"`
+	Phone string `json:"phone" validate:"omitempty,e164"`
+}
+
+// VerifyPhoneReq represents the request payload for verifying the phone of a user with a texted code.
+type VerifyPhoneReq struct {
+	ID   int64  `json:"id"`
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// PhoneOTPReq represents the request payload for texting a login code to a phone.
+type PhoneOTPReq struct {
+	Phone string `json:"phone" validate:"required,e164"`
+}
+
+// PhoneLoginReq represents the request payload for logging in with a phone and a texted code.
+type PhoneLoginReq struct {
+	Phone       string `json:"phone" validate:"required,e164"`
+	Code        string `json:"code" validate:"required,numeric,len=6"`
+	DeviceLabel string `json:"device_label" validate:"max=255"`
+	IP          string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+// EmailChange represents a pending change of a user's email, confirmed from the new address
+// and cancellable from the old one. Only the SHA-256 digests of both tokens are stored.
+type EmailChange struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	NewEmail        string    `json:"new_email"`
+	TokenHash       string    `json:"-"`
+	CancelTokenHash string    `json:"-"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// ChangeEmailReq represents the request payload for changing the email of a user.
+type ChangeEmailReq struct {
+	ID       int64  `json:"id"`
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	IP       string `json:"-"`
+}
+
+// EmailChangeTokenReq represents the request payload for confirming or cancelling an email change.
+type EmailChangeTokenReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ImpersonateReq represents the request payload for an admin acting as a user.
+type ImpersonateReq struct {
+	ID      int64  `json:"id"`
+	AdminID int64  `json:"-"`
+	Reason  string `json:"reason" validate:"required,max=255"`
+	IP      string `json:"-"`
+}
+
+// ImpersonateRes represents the short lived access token of an admin acting as a user, it can't be refreshed.
+type ImpersonateRes struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ImpersonationAudit represents a request made by an admin acting as a user.
+type ImpersonationAudit struct {
+	ID        int64     `json:"id"`
+	ActorID   int64     `json:"actor_id"`
+	UserID    int64     `json:"user_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    *int      `json:"status"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReauthenticateReq represents the request payload for proving the credentials again, with the password or a
+// second factor code.
+type ReauthenticateReq struct {
+	UserID    int64  `json:"-"`
+	SessionID string `json:"-"`
+	Password  string `json:"password" validate:"required_without=Code"`
+	Code      string `json:"code" validate:"required_without=Password"`
+	IP        string `json:"-"`
+}
+
+// ReauthenticateRes represents the response of a re-authentication, the refresh token of the session stays the same.
+type ReauthenticateRes struct {
 	AccessToken string `json:"access_token"`
 }
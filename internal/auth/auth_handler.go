@@ -1,9 +1,16 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/aslam-ep/go-e-commerce/config"
 	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 // Handler handles HTTP requests related to authentication.
@@ -59,6 +66,8 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 // @Success      200  {object}  LoginRes "Login response"
 // @Failure      400  {object}  utils.MessageRes "Default response"
 // @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
 // @Router       /auth/login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginReq
@@ -66,8 +75,18 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	req.IP = utils.ClientIP(r)
+	req.UserAgent = r.UserAgent()
 
 	res, err := h.service.Authenticate(r.Context(), &req)
+	if errors.Is(err, ErrLoginThrottled) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		utils.WriterErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
 		return
@@ -102,3 +121,1175 @@ func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteResponse(w, http.StatusAccepted, res)
 }
+
+// Logout        godoc
+// @Summary      Logout
+// @Description  Logout the session, revokes the refresh token and the access tokens issued from it
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  RefreshTokenReq  true  "Logout request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Router       /auth/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.Logout(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// LogoutAll     godoc
+// @Summary      Logout from all sessions
+// @Description  Logout the user everywhere, revokes all the refresh tokens of the user and the access tokens issued from them
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/logout-all [post]
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.LogoutAll(r.Context(), userID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// VerifyEmail   godoc
+// @Summary      Verify email
+// @Description  Verify the user email with the token sent on registration
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  VerifyEmailReq  true  "Verify email request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/verify-email [post]
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.VerifyEmail(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  Send a new verification email, responds the same whether or not the email exists
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ResendVerificationReq  true  "Resend verification request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/resend-verification [post]
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ResendVerification(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ForgotPassword godoc
+// @Summary      Forgot password
+// @Description  Send a password reset email, responds the same whether or not the email exists
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ForgotPasswordReq  true  "Forgot password request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/forgot-password [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ForgotPassword(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password with the token from the password reset email, revokes all the sessions of the user
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ResetPasswordReq  true  "Reset password request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/reset-password [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ResetPassword(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// LoginMFA      godoc
+// @Summary      Complete two-factor login
+// @Description  Exchange the mfa token from the login along with a TOTP or recovery code, on success get the refreshToken and accessToken
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  LoginMFAReq  true  "Two-factor login request"
+// @Success      200  {object}  LoginRes "Login response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
+// @Router       /auth/login/mfa [post]
+func (h *Handler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req LoginMFAReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req.IP = utils.ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	res, err := h.service.LoginMFA(r.Context(), &req)
+	if errors.Is(err, ErrLoginThrottled) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusAccepted, res)
+}
+
+// EnrollMFA     godoc
+// @Summary      Start two-factor enrollment
+// @Description  Create a new TOTP secret for the user, it's enabled once confirmed with a first code, needs a login or re-authentication within the last 5 minutes
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {object}  EnrollMFARes
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/mfa/enroll [post]
+func (h *Handler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.EnrollMFA(r.Context(), userID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ConfirmMFA    godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Enable two-factor authentication with a first TOTP code, responds with the one time recovery codes, needs a login or re-authentication within the last 5 minutes
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  ConfirmMFAReq  true  "Confirm two-factor request"
+// @Success      200  {object}  ConfirmMFARes
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/mfa/confirm [post]
+func (h *Handler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req ConfirmMFAReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = int64(userID)
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ConfirmMFA(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// DisableMFA    godoc
+// @Summary      Disable two-factor authentication
+// @Description  Disable two-factor authentication, needs the password and a TOTP or recovery code, needs a login or re-authentication within the last 5 minutes
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  DisableMFAReq  true  "Disable two-factor request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/mfa/disable [post]
+func (h *Handler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req DisableMFAReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = int64(userID)
+	req.IP = utils.ClientIP(r)
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.DisableMFA(r.Context(), &req)
+	if errors.Is(err, ErrLoginThrottled) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// UnlockUser    godoc
+// @Summary      Unlock user account
+// @Description  Clear the failed logins and the lockout of the user account, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /admin/users/{user_id}/unlock [post]
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := UnlockUserReq{
+		ID: int64(userID),
+	}
+	if claims, ok := utils.GetClaims(r.Context()); ok {
+		if adminID, err := claims.UserID(); err == nil {
+			req.AdminID = int64(adminID)
+		}
+	}
+
+	res, err := h.service.UnlockUser(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// SuspendUser   godoc
+// @Summary      Suspend user account
+// @Description  Block the user from logging in and revoke every session of the user, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  SuspendUserReq  false  "Suspend request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /admin/users/{user_id}/suspend [post]
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	req, err := readSuspendUserReq(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SuspendUser(r.Context(), req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// UnsuspendUser godoc
+// @Summary      Unsuspend user account
+// @Description  Lift the suspension of the user account, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  SuspendUserReq  false  "Unsuspend request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /admin/users/{user_id}/unsuspend [post]
+func (h *Handler) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	req, err := readSuspendUserReq(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.UnsuspendUser(r.Context(), req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// readSuspendUserReq reads the optional reason from the body, the user from the url and the admin from the claims.
+func readSuspendUserReq(r *http.Request) (*SuspendUserReq, error) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		return nil, err
+	}
+
+	var req SuspendUserReq
+	if r.ContentLength != 0 {
+		if err := utils.ReadFromRequest(r, &req); err != nil {
+			return nil, err
+		}
+	}
+	req.ID = int64(userID)
+
+	if claims, ok := utils.GetClaims(r.Context()); ok {
+		if adminID, err := claims.UserID(); err == nil {
+			req.AdminID = int64(adminID)
+		}
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// Reauthenticate godoc
+// @Summary      Reauthenticate
+// @Description  Prove the credentials again with the password or a TOTP or recovery code, for an access token allowing the sensitive operations like deleting the account
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ReauthenticateReq  true  "Reauthenticate request"
+// @Success      200  {object}  ReauthenticateRes
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
+// @Router       /auth/reauthenticate [post]
+func (h *Handler) Reauthenticate(w http.ResponseWriter, r *http.Request) {
+	var req ReauthenticateReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.IP = utils.ClientIP(r)
+
+	claims, ok := utils.GetClaims(r.Context())
+	if !ok || claims.SessionID == "" {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+	req.UserID = int64(userID)
+	req.SessionID = claims.SessionID
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.Reauthenticate(r.Context(), &req)
+	if errors.Is(err, ErrLoginThrottled) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		utils.WriterErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ImpersonateUser godoc
+// @Summary      Impersonate user
+// @Description  Get a short lived access token for acting as the user, every request made with it is audited. Changing the password, deleting the account and paying aren't allowed with it
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  ImpersonateReq  true  "Impersonation request"
+// @Success      200  {object}  ImpersonateRes
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /admin/users/{user_id}/impersonate [post]
+func (h *Handler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req ImpersonateReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = int64(userID)
+	req.IP = utils.ClientIP(r)
+
+	if claims, ok := utils.GetClaims(r.Context()); ok {
+		if adminID, err := claims.UserID(); err == nil {
+			req.AdminID = int64(adminID)
+		}
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ImpersonateUser(r.Context(), &req)
+	if errors.Is(err, ErrImpersonationNotAllowed) || errors.Is(err, ErrAccountSuspended) {
+		utils.WriterErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// AuditImpersonation writes a request made with an impersonation token to the audit log before it's served, called by
+// the auth middleware. The returned function records the response status once the request is served.
+func (h *Handler) AuditImpersonation(r *http.Request) (func(status int), error) {
+	claims, ok := utils.GetClaims(r.Context())
+	actor, actorOK := utils.GetActor(r.Context())
+	if !ok || !actorOK {
+		return nil, errors.New("impersonation claims not found")
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, err
+	}
+	actorID, err := actor.UserID()
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.service.RecordImpersonatedRequest(r.Context(), &ImpersonationAudit{
+		ActorID: int64(actorID),
+		UserID:  int64(userID),
+		Method:  r.Method,
+		Path:    r.URL.RequestURI(),
+		IP:      utils.ClientIP(r),
+	})
+	if err != nil {
+		log.Printf("Failed to audit request %s %s of admin %d acting as user %d: %v", r.Method, r.URL.Path, actorID, userID, err)
+		return nil, err
+	}
+
+	// Recorded even if the client went away meanwhile
+	ctx := context.WithoutCancel(r.Context())
+
+	return func(status int) {
+		if err := h.service.RecordImpersonatedStatus(ctx, audit.ID, status); err != nil {
+			log.Printf("Failed to record the status of audited request %d: %v", audit.ID, err)
+		}
+	}, nil
+}
+
+// ListSessions  godoc
+// @Summary      List sessions
+// @Description  List the active sessions of the user with their device, the one of the request is flagged as current
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {array}   Session
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/sessions [get]
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var currentSessionID string
+	if claims, ok := utils.GetClaims(r.Context()); ok {
+		currentSessionID = claims.SessionID
+	}
+
+	res, err := h.service.ListSessions(r.Context(), userID, currentSessionID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// RevokeSession godoc
+// @Summary      Revoke session
+// @Description  End a session of the user, its refresh and access tokens stop working
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id          path  int     true  "User ID"
+// @Param        session_id  path  string  true  "Session ID"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      404  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/sessions/{session_id} [delete]
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.RevokeSession(r.Context(), userID, chi.URLParam(r, "session_id"))
+	if errors.Is(err, ErrSessionNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// oidcStateCookie holds the state of the OpenID Connect login started by the browser
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin     godoc
+// @Summary      Start social login
+// @Description  Redirect the browser to the OpenID Connect or OAuth 2.0 provider, which redirects back to the callback
+// @Tags         Auth
+// @Param        provider  path  string  true  "Provider name"
+// @Success      302
+// @Failure      404  {object}  utils.MessageRes "Default response"
+// @Failure      500  {object}  utils.MessageRes "Default response"
+// @Failure      502  {object}  utils.MessageRes "Default response"
+// @Router       /auth/oidc/{provider}/login [get]
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.StartOIDCLogin(r.Context(), chi.URLParam(r, "provider"))
+	if errors.Is(err, ErrUnknownProvider) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrProviderUnavailable) {
+		utils.WriterErrorResponse(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Lax, as the provider redirects back with a top level navigation
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    res.State,
+		Path:     "/",
+		MaxAge:   int(oidcStateExpiry.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, res.AuthURL, http.StatusFound)
+}
+
+// OIDCCallback  godoc
+// @Summary      Complete social login
+// @Description  Verify the OpenID Connect provider response and log in the linked user, the user with the same verified email or a new user
+// @Tags         Auth
+// @Produce      json
+// @Param        provider  path   string  true  "Provider name"
+// @Param        code      query  string  true  "Authorization code"
+// @Param        state     query  string  true  "Login state"
+// @Success      200  {object}  LoginRes "Login response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      404  {object}  utils.MessageRes "Default response"
+// @Router       /auth/oidc/{provider}/callback [get]
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, strings.TrimSpace(providerError+" "+query.Get("error_description")))
+		return
+	}
+
+	req := OIDCCallbackReq{
+		Provider:  chi.URLParam(r, "provider"),
+		Code:      query.Get("code"),
+		State:     query.Get("state"),
+		IP:        utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		req.BrowserState = cookie.Value
+	}
+
+	// The state is single use, whatever the outcome
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.OIDCCallback(r.Context(), &req)
+	if errors.Is(err, ErrUnknownProvider) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		utils.WriterErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// SendPhoneVerification godoc
+// @Summary      Send phone verification code
+// @Description  Text a code for verifying the phone of the user, or a new phone which replaces it once verified. Another code can be asked for once the resend cooldown is over
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  SendPhoneVerificationReq  false  "Send phone verification request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      409  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/phone/send-verification [post]
+func (h *Handler) SendPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req SendPhoneVerificationReq
+	// The body is optional, without it the code is texted to the current phone
+	if r.ContentLength != 0 {
+		if err := utils.ReadFromRequest(r, &req); err != nil {
+			utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	req.ID = int64(userID)
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SendPhoneVerification(r.Context(), &req)
+	if errors.Is(err, ErrPhoneTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, ErrOTPCooldown) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// VerifyPhone   godoc
+// @Summary      Verify phone
+// @Description  Verify the phone the code was texted to and set it as the phone of the user
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  VerifyPhoneReq  true  "Verify phone request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      409  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/phone/verify [post]
+func (h *Handler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req VerifyPhoneReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = int64(userID)
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.VerifyPhone(r.Context(), &req)
+	if errors.Is(err, ErrPhoneTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// PhoneOTP      godoc
+// @Summary      Send phone login code
+// @Description  Text a login code if the phone belongs to an account which verified it, responds the same whether or not the phone exists
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  PhoneOTPReq  true  "Phone login code request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/phone/otp [post]
+func (h *Handler) PhoneOTP(w http.ResponseWriter, r *http.Request) {
+	var req PhoneOTPReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SendPhoneLoginOTP(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// PhoneLogin    godoc
+// @Summary      Login with phone
+// @Description  Login with a verified phone and the texted login code, on success get the refreshToken and accessToken
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  PhoneLoginReq  true  "Phone login request"
+// @Success      200  {object}  LoginRes "Login response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
+// @Router       /auth/phone/login [post]
+func (h *Handler) PhoneLogin(w http.ResponseWriter, r *http.Request) {
+	var req PhoneLoginReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.IP = utils.ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.PhoneLogin(r.Context(), &req)
+	if errors.Is(err, ErrLoginThrottled) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		utils.WriterErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ChangeEmail   godoc
+// @Summary      Change email
+// @Description  Start changing the email of the user, needs the password and a login or re-authentication within the last 5 minutes. A confirmation link is mailed to the new email and a cancel link to the current one
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  ChangeEmailReq  true  "Change email request"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Failure      409  {object}  utils.MessageRes "Default response"
+// @Failure      429  {object}  utils.MessageRes "Default response"
+// @Router       /users/{user_id}/change-email [post]
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req ChangeEmailReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = int64(userID)
+	req.IP = utils.ClientIP(r)
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ChangeEmail(r.Context(), &req)
+	if errors.Is(err, ErrLoginThrottled) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, ErrEmailTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ConfirmEmailChange godoc
+// @Summary      Confirm email change
+// @Description  Confirm the new email with the token mailed to it, every session of the user is revoked
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  EmailChangeTokenReq  true  "Confirmation token"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      409  {object}  utils.MessageRes "Default response"
+// @Router       /auth/change-email/confirm [post]
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req EmailChangeTokenReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ConfirmEmailChange(r.Context(), &req)
+	if errors.Is(err, ErrEmailTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// CancelEmailChange godoc
+// @Summary      Cancel email change
+// @Description  Cancel a pending email change with the token mailed to the current email
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  EmailChangeTokenReq  true  "Cancel token"
+// @Success      200  {object}  utils.MessageRes "Default response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/change-email/cancel [post]
+func (h *Handler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req EmailChangeTokenReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CancelEmailChange(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// magicLinkNonceCookie holds the nonce binding the login links to the browser which asked for them
+const magicLinkNonceCookie = "magic_link_nonce"
+
+// MagicLink     godoc
+// @Summary      Send login link
+// @Description  Mail a single use login link if the email belongs to an account whose role allows it, the response is the same otherwise. The link only works along with the returned nonce, also set as a cookie
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  MagicLinkReq  true  "Login link request"
+// @Success      200  {object}  MagicLinkRes "Login link response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Router       /auth/magic-link [post]
+func (h *Handler) MagicLink(w http.ResponseWriter, r *http.Request) {
+	var req MagicLinkReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SendMagicLink(r.Context(), &req)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkNonceCookie,
+		Value:    res.Nonce,
+		Path:     "/",
+		MaxAge:   config.AppConfig.MagicLinkMinutes * 60,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ConsumeMagicLink godoc
+// @Summary      Log in with login link
+// @Description  Log in with the token of a mailed login link, on the device which asked for it, on success get the refreshToken and accessToken
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ConsumeMagicLinkReq  true  "Login link token"
+// @Success      200  {object}  LoginRes "Login response"
+// @Failure      400  {object}  utils.MessageRes "Default response"
+// @Failure      401  {object}  utils.MessageRes "Default response"
+// @Failure      403  {object}  utils.MessageRes "Default response"
+// @Router       /auth/magic-link/consume [post]
+func (h *Handler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req ConsumeMagicLinkReq
+	if err := utils.ReadFromRequest(r, &req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Nonce == "" {
+		if cookie, err := r.Cookie(magicLinkNonceCookie); err == nil {
+			req.Nonce = cookie.Value
+		}
+	}
+	req.IP = utils.ClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	if err := utils.Validate.Struct(req); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ConsumeMagicLink(r.Context(), &req)
+	if errors.Is(err, ErrAccountSuspended) {
+		utils.WriterErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// JWKS publishes the public keys which verify the access tokens, so other services
+// can verify them without holding the signing secret.
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	utils.WriteResponse(w, http.StatusOK, utils.SigningKeys.JWKS())
+}
@@ -3,6 +3,10 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
 )
 
 // Repository interface for auth repository
@@ -10,11 +14,144 @@ type Repository interface {
 	// Save stores a new refresh token in the data store.
 	Save(ctx context.Context, refreshToken *RefreshToken) (*RefreshToken, error)
 
-	// Delete removes a refresh token from the data store.
-	Delete(ctx context.Context, refreshTokenID int) error
+	// DeleteByFamily removes the session of the login along with every refresh token rotated from it.
+	DeleteByFamily(ctx context.Context, familyID string) error
+
+	// DeleteByUserID removes every session and refresh token of the user and returns the removed family ids.
+	DeleteByUserID(ctx context.Context, userID int) ([]string, error)
+
+	// SaveSession stores a new session in the data store.
+	SaveSession(ctx context.Context, session *Session) (*Session, error)
+
+	// TouchSession records the use of the session, extends it until the given expiry and returns when it was last authenticated.
+	TouchSession(ctx context.Context, sessionID string, expiresAt time.Time) (time.Time, error)
+
+	// MarkSessionAuthenticated records that the user proved their credentials again in the session and returns when.
+	MarkSessionAuthenticated(ctx context.Context, userID int, sessionID string) (time.Time, error)
+
+	// FindSessions retrieves the unexpired sessions of the user, the most recently used first.
+	FindSessions(ctx context.Context, userID int) ([]*Session, error)
+
+	// DeleteSession removes the session of the user along with its refresh tokens, returns false if no such session exists.
+	DeleteSession(ctx context.Context, userID int, sessionID string) (bool, error)
+
+	// DeleteExpired removes the expired sessions, refresh tokens, pending logins, phone codes and email changes and returns how many rows were removed.
+	DeleteExpired(ctx context.Context) (int64, error)
+
+	// Consume marks an unused refresh token as consumed, returns false if it was already consumed.
+	Consume(ctx context.Context, refreshTokenID int) (bool, error)
+
+	// FindByTokenHash retrieves a refresh token by the digest of its token string from the data store.
+	FindByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+
+	// SaveEmailVerification stores a new email verification token in the data store.
+	SaveEmailVerification(ctx context.Context, verification *EmailVerification) (*EmailVerification, error)
+
+	// ConsumeEmailVerification removes an unexpired email verification token by its digest and returns it.
+	ConsumeEmailVerification(ctx context.Context, tokenHash string) (*EmailVerification, error)
+
+	// DeleteEmailVerifications removes every email verification token of the user.
+	DeleteEmailVerifications(ctx context.Context, userID int) error
+
+	// SavePasswordReset stores a new password reset token in the data store.
+	SavePasswordReset(ctx context.Context, reset *PasswordReset) (*PasswordReset, error)
+
+	// ConsumePasswordReset removes an unexpired password reset token by its digest and returns it.
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (*PasswordReset, error)
+
+	// DeletePasswordResets removes every password reset token of the user.
+	DeletePasswordResets(ctx context.Context, userID int) error
+
+	// SaveMFA stores a new unconfirmed TOTP secret for the user, replacing a previous unconfirmed one.
+	SaveMFA(ctx context.Context, mfa *MFA) (*MFA, error)
+
+	// FindMFA retrieves the TOTP second factor of the user.
+	FindMFA(ctx context.Context, userID int) (*MFA, error)
+
+	// ConfirmMFA enables the TOTP second factor of the user.
+	ConfirmMFA(ctx context.Context, userID int) error
+
+	// UseMFAStep records the time step of an accepted code, returns false if it or a later one was already used.
+	UseMFAStep(ctx context.Context, userID int, step int64) (bool, error)
+
+	// DeleteMFA removes the TOTP second factor and the recovery codes of the user.
+	DeleteMFA(ctx context.Context, userID int) error
+
+	// SaveRecoveryCodes replaces the recovery codes of the user with the given digests.
+	SaveRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+
+	// UseRecoveryCode marks an unused recovery code as used, returns false if no such code exists.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+
+	// FindLoginThrottle retrieves the failed login attempts recorded for the key.
+	FindLoginThrottle(ctx context.Context, key string) (*LoginThrottle, error)
+
+	// RecordLoginFailure counts a failed login for the key, restarting the count when the last failure is older than window.
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration) (*LoginThrottle, error)
+
+	// LockLogin blocks the logins for the key until the given time.
+	LockLogin(ctx context.Context, key string, until time.Time) error
+
+	// ClearLoginThrottle removes the failed login attempts and the lock of the key.
+	ClearLoginThrottle(ctx context.Context, key string) error
+
+	// SaveAuthEvent stores a security event in the data store.
+	SaveAuthEvent(ctx context.Context, event *AuthEvent) (*AuthEvent, error)
 
-	// FindByToken retrieves a refresh token by its token string from the data store.
-	FindByToken(ctx context.Context, token string) (*RefreshToken, error)
+	// SaveOIDCState stores a pending OpenID Connect login in the data store.
+	SaveOIDCState(ctx context.Context, state *OIDCState) (*OIDCState, error)
+
+	// ConsumeOIDCState removes an unexpired pending OpenID Connect login by the digest of its state and returns it.
+	ConsumeOIDCState(ctx context.Context, stateHash string) (*OIDCState, error)
+
+	// FindUserIdentity retrieves the identity linked for the subject at the provider.
+	FindUserIdentity(ctx context.Context, provider, subject string) (*UserIdentity, error)
+
+	// SaveUserIdentity links a provider identity to a user.
+	SaveUserIdentity(ctx context.Context, identity *UserIdentity) (*UserIdentity, error)
+
+	// SaveMFAChallenge stores a new pending two-factor login in the data store.
+	SaveMFAChallenge(ctx context.Context, challenge *MFAChallenge) error
+
+	// UseMFAChallengeAttempt counts a guess on the unexpired pending two-factor login and returns it,
+	// unless maxAttempts guesses were already made.
+	UseMFAChallengeAttempt(ctx context.Context, id string, maxAttempts int) (*MFAChallenge, error)
+
+	// DeleteMFAChallenge removes the pending two-factor login, returns false if it was already removed.
+	DeleteMFAChallenge(ctx context.Context, id string) (bool, error)
+
+	// SaveMagicLink stores a new login link token in the data store.
+	SaveMagicLink(ctx context.Context, magicLink *MagicLink) (*MagicLink, error)
+
+	// ConsumeMagicLink removes an unexpired login link token by its digest and returns it.
+	ConsumeMagicLink(ctx context.Context, tokenHash string) (*MagicLink, error)
+
+	// SavePhoneOTP stores a new phone code in place of the pending one of the user for the purpose,
+	// returns false without storing it if the pending one was sent after resendAfter.
+	SavePhoneOTP(ctx context.Context, otp *PhoneOTP, resendAfter time.Time) (bool, error)
+
+	// UsePhoneOTPAttempt counts a guess on the unexpired phone code of the user for the purpose and returns it,
+	// unless maxAttempts guesses were already made.
+	UsePhoneOTPAttempt(ctx context.Context, userID int, purpose string, maxAttempts int) (*PhoneOTP, error)
+
+	// DeletePhoneOTP removes the phone code by its id, returns false if it was already removed.
+	DeletePhoneOTP(ctx context.Context, id int) (bool, error)
+
+	// SaveEmailChange stores a new email change in place of the pending one of the user.
+	SaveEmailChange(ctx context.Context, change *EmailChange) (*EmailChange, error)
+
+	// ConfirmEmailChange removes an unexpired email change by the digest of its token and sets the new email of the user
+	// in one transaction, returns ErrEmailTaken if another user has the new email by now.
+	ConfirmEmailChange(ctx context.Context, tokenHash string) (*EmailChange, error)
+
+	// CancelEmailChange removes an email change by the digest of its cancel token and returns it.
+	CancelEmailChange(ctx context.Context, cancelTokenHash string) (*EmailChange, error)
+
+	// SaveImpersonationAudit stores a request made by an admin acting as a user.
+	SaveImpersonationAudit(ctx context.Context, audit *ImpersonationAudit) (*ImpersonationAudit, error)
+
+	// SetImpersonationAuditStatus records the response status of an audited request once it's served.
+	SetImpersonationAuditStatus(ctx context.Context, auditID int64, status int) error
 }
 
 type repository struct {
@@ -30,11 +167,12 @@ func NewRepository(db *sql.DB) Repository {
 
 func (r *repository) Save(ctx context.Context, refreshToken *RefreshToken) (*RefreshToken, error) {
 	var refreshTokenID int
-	insertQuery := `INSERT INTO refresh_tokens(user_id, token, expires_at) VALUES ($1, $2, $3) RETURNING id`
+	insertQuery := `INSERT INTO refresh_tokens(user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id`
 
 	err := r.db.QueryRowContext(ctx, insertQuery,
 		refreshToken.UserID,
-		refreshToken.Token,
+		refreshToken.FamilyID,
+		refreshToken.TokenHash,
 		refreshToken.ExpiresAt,
 	).Scan(&refreshTokenID)
 
@@ -47,23 +185,225 @@ func (r *repository) Save(ctx context.Context, refreshToken *RefreshToken) (*Ref
 	return refreshToken, nil
 }
 
-func (r *repository) Delete(ctx context.Context, refreshTokenID int) error {
-	deleteQuery := `DELETE FROM refresh_tokens WHERE id = $1`
+func (r *repository) DeleteByFamily(ctx context.Context, familyID string) error {
+	// The refresh tokens are removed along with their session
+	deleteQuery := `DELETE FROM sessions WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, deleteQuery, refreshTokenID)
+	_, err := r.db.ExecContext(ctx, deleteQuery, familyID)
 
 	return err
 }
 
-func (r *repository) FindByToken(ctx context.Context, token string) (*RefreshToken, error) {
+func (r *repository) DeleteByUserID(ctx context.Context, userID int) ([]string, error) {
+	deleteQuery := `DELETE FROM sessions WHERE user_id = $1 RETURNING id`
+
+	rows, err := r.db.QueryContext(ctx, deleteQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var familyIDs []string
+	for rows.Next() {
+		var familyID string
+		if err := rows.Scan(&familyID); err != nil {
+			return nil, err
+		}
+		familyIDs = append(familyIDs, familyID)
+	}
+
+	return familyIDs, rows.Err()
+}
+
+func (r *repository) SaveSession(ctx context.Context, session *Session) (*Session, error) {
+	insertQuery := `INSERT INTO sessions(id, user_id, user_agent, ip, device_label, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at, last_used_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		session.ID,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		session.DeviceLabel,
+		session.ExpiresAt,
+	).Scan(&session.CreatedAt, &session.LastUsedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *repository) TouchSession(ctx context.Context, sessionID string, expiresAt time.Time) (time.Time, error) {
+	var authenticatedAt time.Time
+	touchQuery := `UPDATE sessions SET last_used_at = CURRENT_TIMESTAMP, expires_at = $1 WHERE id = $2 RETURNING authenticated_at`
+
+	err := r.db.QueryRowContext(ctx, touchQuery, expiresAt, sessionID).Scan(&authenticatedAt)
+
+	return authenticatedAt, err
+}
+
+func (r *repository) MarkSessionAuthenticated(ctx context.Context, userID int, sessionID string) (time.Time, error) {
+	var authenticatedAt time.Time
+	updateQuery := `UPDATE sessions SET authenticated_at = CURRENT_TIMESTAMP, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND expires_at > CURRENT_TIMESTAMP RETURNING authenticated_at`
+
+	err := r.db.QueryRowContext(ctx, updateQuery, sessionID, userID).Scan(&authenticatedAt)
+
+	return authenticatedAt, err
+}
+
+func (r *repository) FindSessions(ctx context.Context, userID int) ([]*Session, error) {
+	selectQuery := `SELECT id, user_id, user_agent, ip, device_label, created_at, last_used_at, expires_at FROM sessions
+		WHERE user_id = $1 AND expires_at > CURRENT_TIMESTAMP ORDER BY last_used_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.DeviceLabel,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *repository) DeleteSession(ctx context.Context, userID int, sessionID string) (bool, error) {
+	deleteQuery := `DELETE FROM sessions WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Rotated tokens of live sessions are kept only while a replay could still be detected
+	result, err := tx.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	tokensDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	sessionsDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// Logins abandoned at the identity provider
+	result, err = tx.ExecContext(ctx, `DELETE FROM oidc_states WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	statesDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM magic_link_tokens WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	magicLinksDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM phone_otps WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	otpsDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM email_changes WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	emailChangesDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	challengesDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return tokensDeleted + sessionsDeleted + statesDeleted + magicLinksDeleted + otpsDeleted + emailChangesDeleted + challengesDeleted,
+		tx.Commit()
+}
+
+func (r *repository) Consume(ctx context.Context, refreshTokenID int) (bool, error) {
+	consumeQuery := `UPDATE refresh_tokens SET consumed_at = CURRENT_TIMESTAMP WHERE id = $1 AND consumed_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, consumeQuery, refreshTokenID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) FindByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
 	var refreshToken RefreshToken
-	selectQueryByID := `SELECT * FROM refresh_tokens WHERE token = $1 AND expires_at > CURRENT_TIMESTAMP`
+	selectQueryByTokenHash := `SELECT id, user_id, family_id, token_hash, expires_at, consumed_at FROM refresh_tokens WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP`
 
-	err := r.db.QueryRowContext(ctx, selectQueryByID, token).Scan(
+	err := r.db.QueryRowContext(ctx, selectQueryByTokenHash, tokenHash).Scan(
 		&refreshToken.ID,
 		&refreshToken.UserID,
-		&refreshToken.Token,
+		&refreshToken.FamilyID,
+		&refreshToken.TokenHash,
 		&refreshToken.ExpiresAt,
+		&refreshToken.ConsumedAt,
 	)
 
 	if err != nil {
@@ -72,3 +412,636 @@ func (r *repository) FindByToken(ctx context.Context, token string) (*RefreshTok
 
 	return &refreshToken, nil
 }
+
+func (r *repository) SaveEmailVerification(ctx context.Context, verification *EmailVerification) (*EmailVerification, error) {
+	var verificationID int
+	insertQuery := `INSERT INTO email_verification_tokens(user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		verification.UserID,
+		verification.TokenHash,
+		verification.ExpiresAt,
+	).Scan(&verificationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verification.ID = int64(verificationID)
+
+	return verification, nil
+}
+
+func (r *repository) ConsumeEmailVerification(ctx context.Context, tokenHash string) (*EmailVerification, error) {
+	var verification EmailVerification
+	deleteQuery := `DELETE FROM email_verification_tokens WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP RETURNING id, user_id, token_hash, expires_at`
+
+	err := r.db.QueryRowContext(ctx, deleteQuery, tokenHash).Scan(
+		&verification.ID,
+		&verification.UserID,
+		&verification.TokenHash,
+		&verification.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &verification, nil
+}
+
+func (r *repository) DeleteEmailVerifications(ctx context.Context, userID int) error {
+	deleteQuery := `DELETE FROM email_verification_tokens WHERE user_id = $1`
+
+	_, err := r.db.ExecContext(ctx, deleteQuery, userID)
+
+	return err
+}
+
+func (r *repository) SavePasswordReset(ctx context.Context, reset *PasswordReset) (*PasswordReset, error) {
+	var resetID int
+	insertQuery := `INSERT INTO password_reset_tokens(user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		reset.UserID,
+		reset.TokenHash,
+		reset.ExpiresAt,
+	).Scan(&resetID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reset.ID = int64(resetID)
+
+	return reset, nil
+}
+
+func (r *repository) ConsumePasswordReset(ctx context.Context, tokenHash string) (*PasswordReset, error) {
+	var reset PasswordReset
+	deleteQuery := `DELETE FROM password_reset_tokens WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP RETURNING id, user_id, token_hash, expires_at`
+
+	err := r.db.QueryRowContext(ctx, deleteQuery, tokenHash).Scan(
+		&reset.ID,
+		&reset.UserID,
+		&reset.TokenHash,
+		&reset.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+func (r *repository) DeletePasswordResets(ctx context.Context, userID int) error {
+	deleteQuery := `DELETE FROM password_reset_tokens WHERE user_id = $1`
+
+	_, err := r.db.ExecContext(ctx, deleteQuery, userID)
+
+	return err
+}
+
+func (r *repository) SaveMFA(ctx context.Context, mfa *MFA) (*MFA, error) {
+	upsertQuery := `INSERT INTO user_mfa(user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		WHERE user_mfa.confirmed_at IS NULL
+		RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, upsertQuery,
+		mfa.UserID,
+		mfa.Secret,
+	).Scan(&mfa.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mfa, nil
+}
+
+func (r *repository) FindMFA(ctx context.Context, userID int) (*MFA, error) {
+	var mfa MFA
+	selectQueryByUserID := `SELECT user_id, secret, last_used_step, confirmed_at, created_at FROM user_mfa WHERE user_id = $1`
+
+	err := r.db.QueryRowContext(ctx, selectQueryByUserID, userID).Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.LastUsedStep,
+		&mfa.ConfirmedAt,
+		&mfa.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+func (r *repository) ConfirmMFA(ctx context.Context, userID int) error {
+	confirmQuery := `UPDATE user_mfa SET confirmed_at = CURRENT_TIMESTAMP WHERE user_id = $1`
+
+	_, err := r.db.ExecContext(ctx, confirmQuery, userID)
+
+	return err
+}
+
+func (r *repository) UseMFAStep(ctx context.Context, userID int, step int64) (bool, error) {
+	useQuery := `UPDATE user_mfa SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1`
+
+	result, err := r.db.ExecContext(ctx, useQuery, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) DeleteMFA(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) SaveRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	insertQuery := `INSERT INTO mfa_recovery_codes(user_id, code_hash) VALUES ($1, $2)`
+	for _, codeHash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, insertQuery, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	useQuery := `UPDATE mfa_recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, useQuery, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) FindLoginThrottle(ctx context.Context, key string) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	selectQueryByKey := `SELECT key, failed_count, last_failed_at, locked_until FROM login_throttles WHERE key = $1`
+
+	err := r.db.QueryRowContext(ctx, selectQueryByKey, key).Scan(
+		&throttle.Key,
+		&throttle.FailedCount,
+		&throttle.LastFailedAt,
+		&throttle.LockedUntil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+func (r *repository) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	upsertQuery := `INSERT INTO login_throttles(key, failed_count, last_failed_at) VALUES ($1, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE SET
+			failed_count = CASE
+				WHEN login_throttles.last_failed_at < CURRENT_TIMESTAMP - $2 * INTERVAL '1 second' THEN 1
+				ELSE login_throttles.failed_count + 1
+			END,
+			last_failed_at = CURRENT_TIMESTAMP
+		RETURNING key, failed_count, last_failed_at, locked_until`
+
+	err := r.db.QueryRowContext(ctx, upsertQuery, key, int(window.Seconds())).Scan(
+		&throttle.Key,
+		&throttle.FailedCount,
+		&throttle.LastFailedAt,
+		&throttle.LockedUntil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+func (r *repository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	lockQuery := `UPDATE login_throttles SET locked_until = $1 WHERE key = $2`
+
+	_, err := r.db.ExecContext(ctx, lockQuery, until, key)
+
+	return err
+}
+
+func (r *repository) ClearLoginThrottle(ctx context.Context, key string) error {
+	deleteQuery := `DELETE FROM login_throttles WHERE key = $1`
+
+	_, err := r.db.ExecContext(ctx, deleteQuery, key)
+
+	return err
+}
+
+func (r *repository) SaveAuthEvent(ctx context.Context, event *AuthEvent) (*AuthEvent, error) {
+	insertQuery := `INSERT INTO auth_events(user_id, event, ip, details) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		event.UserID,
+		event.Event,
+		event.IP,
+		event.Details,
+	).Scan(&event.ID, &event.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (r *repository) SaveOIDCState(ctx context.Context, state *OIDCState) (*OIDCState, error) {
+	var stateID int
+	insertQuery := `INSERT INTO oidc_states(state_hash, provider, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		state.StateHash,
+		state.Provider,
+		state.Nonce,
+		state.CodeVerifier,
+		state.ExpiresAt,
+	).Scan(&stateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state.ID = int64(stateID)
+
+	return state, nil
+}
+
+func (r *repository) ConsumeOIDCState(ctx context.Context, stateHash string) (*OIDCState, error) {
+	var state OIDCState
+	consumeQuery := `DELETE FROM oidc_states WHERE state_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, state_hash, provider, nonce, code_verifier, expires_at`
+
+	err := r.db.QueryRowContext(ctx, consumeQuery, stateHash).Scan(
+		&state.ID,
+		&state.StateHash,
+		&state.Provider,
+		&state.Nonce,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *repository) FindUserIdentity(ctx context.Context, provider, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	selectQuery := `SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	err := r.db.QueryRowContext(ctx, selectQuery, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *repository) SaveUserIdentity(ctx context.Context, identity *UserIdentity) (*UserIdentity, error) {
+	insertQuery := `INSERT INTO user_identities(user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (r *repository) SaveMagicLink(ctx context.Context, magicLink *MagicLink) (*MagicLink, error) {
+	var magicLinkID int
+	insertQuery := `INSERT INTO magic_link_tokens(user_id, token_hash, nonce_hash, device_label, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		magicLink.UserID,
+		magicLink.TokenHash,
+		magicLink.NonceHash,
+		magicLink.DeviceLabel,
+		magicLink.ExpiresAt,
+	).Scan(&magicLinkID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	magicLink.ID = int64(magicLinkID)
+
+	return magicLink, nil
+}
+
+func (r *repository) ConsumeMagicLink(ctx context.Context, tokenHash string) (*MagicLink, error) {
+	var magicLink MagicLink
+	deleteQuery := `DELETE FROM magic_link_tokens WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id, token_hash, nonce_hash, device_label, expires_at`
+
+	err := r.db.QueryRowContext(ctx, deleteQuery, tokenHash).Scan(
+		&magicLink.ID,
+		&magicLink.UserID,
+		&magicLink.TokenHash,
+		&magicLink.NonceHash,
+		&magicLink.DeviceLabel,
+		&magicLink.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &magicLink, nil
+}
+
+func (r *repository) SaveMFAChallenge(ctx context.Context, challenge *MFAChallenge) error {
+	insertQuery := `INSERT INTO mfa_challenges(id, user_id, expires_at) VALUES ($1, $2, $3)`
+
+	_, err := r.db.ExecContext(ctx, insertQuery, challenge.ID, challenge.UserID, challenge.ExpiresAt)
+	return err
+}
+
+func (r *repository) UseMFAChallengeAttempt(ctx context.Context, id string, maxAttempts int) (*MFAChallenge, error) {
+	var challenge MFAChallenge
+	// Counted before the code is checked, so concurrent guesses can't exceed the limit
+	updateQuery := `UPDATE mfa_challenges SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id, attempts, expires_at`
+
+	err := r.db.QueryRowContext(ctx, updateQuery, id, maxAttempts).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+func (r *repository) DeleteMFAChallenge(ctx context.Context, id string) (bool, error) {
+	deleteQuery := `DELETE FROM mfa_challenges WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) SavePhoneOTP(ctx context.Context, otp *PhoneOTP, resendAfter time.Time) (bool, error) {
+	var otpID int
+	// The pending code is replaced only once the resend cooldown is over, with its guesses reset
+	upsertQuery := `INSERT INTO phone_otps(user_id, purpose, phone, code_hash, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, purpose) DO UPDATE SET phone = EXCLUDED.phone, code_hash = EXCLUDED.code_hash, attempts = 0,
+		expires_at = EXCLUDED.expires_at, created_at = CURRENT_TIMESTAMP
+		WHERE phone_otps.created_at <= $6 RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, upsertQuery,
+		otp.UserID,
+		otp.Purpose,
+		otp.Phone,
+		otp.CodeHash,
+		otp.ExpiresAt,
+		resendAfter,
+	).Scan(&otpID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	otp.ID = int64(otpID)
+
+	return true, nil
+}
+
+func (r *repository) UsePhoneOTPAttempt(ctx context.Context, userID int, purpose string, maxAttempts int) (*PhoneOTP, error) {
+	var otp PhoneOTP
+	// Counted before the code is compared, so concurrent guesses can't exceed the limit
+	updateQuery := `UPDATE phone_otps SET attempts = attempts + 1
+		WHERE user_id = $1 AND purpose = $2 AND attempts < $3 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id, purpose, phone, code_hash, attempts, expires_at`
+
+	err := r.db.QueryRowContext(ctx, updateQuery, userID, purpose, maxAttempts).Scan(
+		&otp.ID,
+		&otp.UserID,
+		&otp.Purpose,
+		&otp.Phone,
+		&otp.CodeHash,
+		&otp.Attempts,
+		&otp.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp, nil
+}
+
+func (r *repository) DeletePhoneOTP(ctx context.Context, id int) (bool, error) {
+	deleteQuery := `DELETE FROM phone_otps WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *repository) SaveEmailChange(ctx context.Context, change *EmailChange) (*EmailChange, error) {
+	var changeID int
+	upsertQuery := `INSERT INTO email_changes(user_id, new_email, token_hash, cancel_token_hash, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET new_email = EXCLUDED.new_email, token_hash = EXCLUDED.token_hash,
+		cancel_token_hash = EXCLUDED.cancel_token_hash, expires_at = EXCLUDED.expires_at, created_at = CURRENT_TIMESTAMP
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, upsertQuery,
+		change.UserID,
+		change.NewEmail,
+		change.TokenHash,
+		change.CancelTokenHash,
+		change.ExpiresAt,
+	).Scan(&changeID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	change.ID = int64(changeID)
+
+	return change, nil
+}
+
+func (r *repository) ConfirmEmailChange(ctx context.Context, tokenHash string) (*EmailChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var change EmailChange
+	deleteQuery := `DELETE FROM email_changes WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id, new_email, token_hash, cancel_token_hash, expires_at`
+
+	err = tx.QueryRowContext(ctx, deleteQuery, tokenHash).Scan(
+		&change.ID,
+		&change.UserID,
+		&change.NewEmail,
+		&change.TokenHash,
+		&change.CancelTokenHash,
+		&change.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Opening the link proves the ownership of the new email, the unique constraint settles a race for it
+	updateQuery := `UPDATE users SET email = $1, email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	_, err = tx.ExecContext(ctx, updateQuery, change.NewEmail, change.UserID)
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The tokens mailed to the old email must not work anymore
+	for _, table := range []string{"email_verification_tokens", "password_reset_tokens", "magic_link_tokens"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, change.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &change, tx.Commit()
+}
+
+func (r *repository) CancelEmailChange(ctx context.Context, cancelTokenHash string) (*EmailChange, error) {
+	var change EmailChange
+	deleteQuery := `DELETE FROM email_changes WHERE cancel_token_hash = $1
+		RETURNING id, user_id, new_email, token_hash, cancel_token_hash, expires_at`
+
+	err := r.db.QueryRowContext(ctx, deleteQuery, cancelTokenHash).Scan(
+		&change.ID,
+		&change.UserID,
+		&change.NewEmail,
+		&change.TokenHash,
+		&change.CancelTokenHash,
+		&change.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func (r *repository) SaveImpersonationAudit(ctx context.Context, audit *ImpersonationAudit) (*ImpersonationAudit, error) {
+	insertQuery := `INSERT INTO impersonation_audit_log(actor_id, user_id, method, path, status, ip) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		audit.ActorID,
+		audit.UserID,
+		audit.Method,
+		audit.Path,
+		audit.Status,
+		audit.IP,
+	).Scan(&audit.ID, &audit.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
+
+func (r *repository) SetImpersonationAuditStatus(ctx context.Context, auditID int64, status int) error {
+	updateQuery := `UPDATE impersonation_audit_log SET status = $2 WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, updateQuery, auditID, status)
+
+	return err
+}
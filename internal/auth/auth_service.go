@@ -2,10 +2,21 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/internal/mailer"
+	"github.com/aslam-ep/go-e-commerce/internal/role"
+	"github.com/aslam-ep/go-e-commerce/internal/sms"
 	"github.com/aslam-ep/go-e-commerce/internal/user"
 	"github.com/aslam-ep/go-e-commerce/utils"
 )
@@ -18,24 +29,195 @@ type Service interface {
 	// Authenticate checks the provided login credentials and returns a login response.
 	Authenticate(ctx context.Context, req *LoginReq) (*LoginRes, error)
 
-	// RefreshToken verifies the provided refresh token and issues a new access token.
+	// RefreshToken consumes the provided refresh token and issues a new access and refresh token pair.
 	RefreshToken(ctx context.Context, req *RefreshTokenReq) (*RefreshTokenRes, error)
+
+	// Logout revokes the refresh token family of the provided token along with the access tokens issued from it.
+	Logout(ctx context.Context, req *RefreshTokenReq) (*utils.MessageRes, error)
+
+	// LogoutAll revokes every session of the user along with the access tokens issued from them.
+	LogoutAll(ctx context.Context, userID int) (*utils.MessageRes, error)
+
+	// VerifyEmail consumes the provided email verification token and marks the user's email as verified.
+	VerifyEmail(ctx context.Context, req *VerifyEmailReq) (*utils.MessageRes, error)
+
+	// ResendVerification sends a new email verification token if the email belongs to an unverified user.
+	ResendVerification(ctx context.Context, req *ResendVerificationReq) (*utils.MessageRes, error)
+
+	// ForgotPassword mails a password reset token if the email belongs to a user.
+	ForgotPassword(ctx context.Context, req *ForgotPasswordReq) (*utils.MessageRes, error)
+
+	// ResetPassword consumes the provided reset token, sets the new password and revokes every session of the user.
+	ResetPassword(ctx context.Context, req *ResetPasswordReq) (*utils.MessageRes, error)
+
+	// LoginMFA exchanges the MFA token of a password login along with a second factor code for a login response.
+	LoginMFA(ctx context.Context, req *LoginMFAReq) (*LoginRes, error)
+
+	// EnrollMFA creates a new TOTP secret for the user, which is enabled once confirmed.
+	EnrollMFA(ctx context.Context, userID int) (*EnrollMFARes, error)
+
+	// ConfirmMFA enables the TOTP second factor with a first code and returns the recovery codes.
+	ConfirmMFA(ctx context.Context, req *ConfirmMFAReq) (*ConfirmMFARes, error)
+
+	// DisableMFA removes the second factor after checking the password and a second factor code.
+	DisableMFA(ctx context.Context, req *DisableMFAReq) (*utils.MessageRes, error)
+
+	// UnlockUser clears the failed logins and the lockout of the user's account.
+	UnlockUser(ctx context.Context, req *UnlockUserReq) (*utils.MessageRes, error)
+
+	// SuspendUser blocks the user from logging in and revokes every session of the user.
+	SuspendUser(ctx context.Context, req *SuspendUserReq) (*utils.MessageRes, error)
+
+	// UnsuspendUser lifts the suspension of the user.
+	UnsuspendUser(ctx context.Context, req *SuspendUserReq) (*utils.MessageRes, error)
+
+	// ListSessions returns the active sessions of the user, flagging the one the request is made from.
+	ListSessions(ctx context.Context, userID int, currentSessionID string) ([]*Session, error)
+
+	// RevokeSession ends a session of the user along with the access tokens issued from it.
+	RevokeSession(ctx context.Context, userID int, sessionID string) (*utils.MessageRes, error)
+
+	// StartOIDCLogin starts a login at the OpenID Connect provider and returns the url to redirect the browser to.
+	StartOIDCLogin(ctx context.Context, provider string) (*OIDCLoginRes, error)
+
+	// OIDCCallback verifies the provider response and logs in the linked user, the user with the same verified email
+	// or a newly provisioned user.
+	OIDCCallback(ctx context.Context, req *OIDCCallbackReq) (*LoginRes, error)
+
+	// SendMagicLink mails a single use login link if the email belongs to a user whose role allows it.
+	SendMagicLink(ctx context.Context, req *MagicLinkReq) (*MagicLinkRes, error)
+
+	// ConsumeMagicLink logs in with a mailed login link, on the device which asked for it.
+	ConsumeMagicLink(ctx context.Context, req *ConsumeMagicLinkReq) (*LoginRes, error)
+
+	// SendPhoneVerification texts a code for verifying the current or a new phone of the user.
+	SendPhoneVerification(ctx context.Context, req *SendPhoneVerificationReq) (*utils.MessageRes, error)
+
+	// VerifyPhone checks the texted code and sets the phone it was texted to as the user's verified phone.
+	VerifyPhone(ctx context.Context, req *VerifyPhoneReq) (*utils.MessageRes, error)
+
+	// SendPhoneLoginOTP texts a login code if the phone belongs to a user who verified it.
+	SendPhoneLoginOTP(ctx context.Context, req *PhoneOTPReq) (*utils.MessageRes, error)
+
+	// PhoneLogin checks the texted login code of the phone and returns a login response.
+	PhoneLogin(ctx context.Context, req *PhoneLoginReq) (*LoginRes, error)
+
+	// ChangeEmail records the new email of the user, mailing a confirmation link to it and a cancel link to the old one.
+	ChangeEmail(ctx context.Context, req *ChangeEmailReq) (*utils.MessageRes, error)
+
+	// ConfirmEmailChange swaps in the new email of a pending change and revokes every session of the user.
+	ConfirmEmailChange(ctx context.Context, req *EmailChangeTokenReq) (*utils.MessageRes, error)
+
+	// CancelEmailChange drops a pending email change from the link mailed to the old email.
+	CancelEmailChange(ctx context.Context, req *EmailChangeTokenReq) (*utils.MessageRes, error)
+
+	// ImpersonateUser issues a short lived access token for an admin to act as the user.
+	ImpersonateUser(ctx context.Context, req *ImpersonateReq) (*ImpersonateRes, error)
+
+	// RecordImpersonatedRequest writes a request made by an admin acting as a user to the audit log, before it's served.
+	RecordImpersonatedRequest(ctx context.Context, audit *ImpersonationAudit) (*ImpersonationAudit, error)
+
+	// RecordImpersonatedStatus completes the audit log entry of a request with its response status.
+	RecordImpersonatedStatus(ctx context.Context, auditID int64, status int) error
+
+	// Reauthenticate checks the password or a second factor code of the user again and returns an access token
+	// marked as recently authenticated, for the sensitive operations.
+	Reauthenticate(ctx context.Context, req *ReauthenticateReq) (*ReauthenticateRes, error)
+
+	// CheckPassword checks the password of the user for a sensitive operation of the user domain, throttled like the
+	// logins. Returns user.ErrPasswordMismatch for a wrong password and user.ErrTooManyAttempts while throttled.
+	CheckPassword(ctx context.Context, u *user.User, password string, ip string) error
+
+	// RevokeUserSessions ends every session of the user along with the access tokens issued from them, for the changes
+	// of the user domain which the tokens mustn't outlive.
+	RevokeUserSessions(ctx context.Context, userID int64) error
 }
 
+// ErrLoginThrottled is returned when the logins are blocked for the account or the IP address.
+var ErrLoginThrottled = errors.New("too many failed login attempts, try again later")
+
+// ErrAccountSuspended is returned when a suspended user tries to log in.
+var ErrAccountSuspended = errors.New("account suspended")
+
+// ErrSessionNotFound is returned when the session doesn't exist or belongs to another user.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrUnknownProvider is returned for an OpenID Connect provider which isn't configured.
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// ErrProviderUnavailable is returned when the login can't be started with the identity provider, like when its
+// discovery fails.
+var ErrProviderUnavailable = errors.New("the identity provider is unavailable, try again later")
+
+// ErrProviderLoginFailed is returned when the identity provider response can't be redeemed or verified.
+var ErrProviderLoginFailed = errors.New("login with the identity provider failed")
+
+// ErrPhoneTaken is returned when the phone being verified belongs to another user.
+var ErrPhoneTaken = errors.New("phone number already in use")
+
+// ErrEmailTaken is returned when the new email of a change belongs to another user.
+var ErrEmailTaken = errors.New("email already in use")
+
+// ErrImpersonationNotAllowed is returned when the user can't be impersonated, like another admin.
+var ErrImpersonationNotAllowed = errors.New("user can't be impersonated")
+
+// ErrOTPCooldown is returned when a phone code is asked for again before the resend cooldown is over.
+var ErrOTPCooldown = errors.New("a code was sent recently, try again later")
+
+// Recorded security events
+const (
+	eventAccountLocked   = "account_locked"
+	eventIPLocked        = "ip_locked"
+	eventAccountUnlocked = "account_unlocked"
+	eventSuspended       = "account_suspended"
+	eventUnsuspended     = "account_unsuspended"
+	eventEmailChanged    = "email_changed"
+	eventPhoneChanged    = "phone_changed"
+	eventImpersonated    = "impersonation_started"
+)
+
+const (
+	accessTokenExpiry       = time.Minute * 15
+	refreshTokenExpiry      = time.Hour * 24 * 7
+	emailVerificationExpiry = time.Hour * 24
+	passwordResetExpiry     = time.Hour
+	mfaTokenExpiry          = time.Minute * 5
+	mfaMaxAttempts          = 5
+	oidcStateExpiry         = time.Minute * 10
+	emailChangeExpiry       = time.Hour * 24
+	impersonationExpiry     = time.Minute * 10
+	recoveryCodeCount       = 10
+	maxLoginBackoff         = time.Minute
+	otpDigits               = 6
+)
+
+// Purposes of the phone codes
+const (
+	otpPurposeVerifyPhone = "verify_phone"
+	otpPurposeLogin       = "login"
+)
+
 type service struct {
-	userRepo user.Repository
-	authRepo Repository
-	timeout  time.Duration
-	secret   string
+	userRepo      user.Repository
+	authRepo      Repository
+	roleRepo      role.Repository
+	mailer        mailer.Mailer
+	smsSender     sms.SMSSender
+	oidcProviders map[string]*oidcProvider
+	timeout       time.Duration
 }
 
 // NewService creates a new instance of the authentication service.
-func NewService(ur user.Repository, ar Repository) Service {
+func NewService(ur user.Repository, ar Repository, rr role.Repository, m mailer.Mailer, ss sms.SMSSender) Service {
 	return &service{
-		userRepo: ur,
-		authRepo: ar,
-		timeout:  time.Duration(config.AppConfig.DBTimeout) * time.Second,
-		secret:   config.AppConfig.JWTSecret,
+		userRepo:  ur,
+		authRepo:  ar,
+		roleRepo:  rr,
+		mailer:    m,
+		smsSender: ss,
+		timeout:   time.Duration(config.AppConfig.DBTimeout) * time.Second,
+
+		oidcProviders: newOIDCProviders(config.AppConfig.OIDCProviders),
 	}
 }
 
@@ -48,11 +230,12 @@ func (s *service) RegisterUser(c context.Context, req *RegisterUserReq) (*user.U
 		return nil, err
 	}
 
+	// Every user registers with the base role, the admins promote them through ChangeRole
 	u := &user.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Phone:    req.Phone,
-		Role:     req.Role,
+		Role:     "user",
 		Password: hashedPassword,
 	}
 
@@ -61,6 +244,11 @@ func (s *service) RegisterUser(c context.Context, req *RegisterUserReq) (*user.U
 		return nil, err
 	}
 
+	// The user can ask for a new verification email, so a failed delivery doesn't fail the registration
+	if err := s.sendEmailVerification(ctx, createdUser); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", createdUser.ID, err)
+	}
+
 	res := &user.User{
 		ID:        createdUser.ID,
 		Name:      createdUser.Name,
@@ -78,61 +266,1676 @@ func (s *service) Authenticate(c context.Context, req *LoginReq) (*LoginRes, err
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
+	// Unknown emails are throttled the same way, so the lockout doesn't tell which accounts exist
+	accountKey := accountThrottleKey(req.Email)
+	ipKey := ipThrottleKey(req.IP)
+	if err := s.checkLoginThrottle(ctx, accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil || !utils.CheckPasswordHash(req.Password, user.Password) {
+		var userID *int64
+		if user != nil {
+			userID = &user.ID
+		}
+		s.recordLoginFailure(ctx, accountKey, userID, req.IP, config.AppConfig.LoginMaxFailures, eventAccountLocked)
+		s.recordLoginFailure(ctx, ipKey, userID, req.IP, config.AppConfig.LoginIPMaxFailures, eventIPLocked)
+
 		return nil, errors.New("invalid credentials")
 	}
 
-	accessToken, err := utils.GenerateToken(user.ID, s.secret, time.Minute*15)
+	err = s.authRepo.ClearLoginThrottle(ctx, accountKey)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := utils.GenerateToken(user.ID, s.secret, time.Hour*24*7)
+	if utils.PasswordNeedsRehash(user.Password) {
+		go s.rehashPassword(user.ID, req.Password, user.Password)
+	}
+
+	return s.completeLogin(ctx, user, req.UserAgent, req.IP, req.DeviceLabel)
+}
+
+func (s *service) RefreshToken(c context.Context, req *RefreshTokenReq) (*RefreshTokenRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	refreshToken, err := s.authRepo.FindByTokenHash(ctx, utils.HashToken(req.RefreshToken))
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = s.authRepo.Save(ctx, &RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 7),
+	// A consumed token is presented again only when it leaked, so the whole family is revoked
+	consumed, err := s.authRepo.Consume(ctx, int(refreshToken.ID))
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		log.Printf("Refresh token reuse detected for user %d, revoking token family %s", refreshToken.UserID, refreshToken.FamilyID)
+
+		if err := s.authRepo.DeleteByFamily(ctx, refreshToken.FamilyID); err != nil {
+			return nil, err
+		}
+		revokeSessions(refreshToken.FamilyID)
+
+		return nil, errors.New("refresh token already used")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, int(refreshToken.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.issueTokens(ctx, user, refreshToken.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RefreshTokenRes{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+
+	return res, nil
+}
+
+func (s *service) Logout(c context.Context, req *RefreshTokenReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	refreshToken, err := s.authRepo.FindByTokenHash(ctx, utils.HashToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.authRepo.DeleteByFamily(ctx, refreshToken.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	revokeSessions(refreshToken.FamilyID)
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Logged out.",
+	}
+
+	return res, nil
+}
+
+func (s *service) LogoutAll(c context.Context, userID int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	familyIDs, err := s.authRepo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revokeSessions(familyIDs...)
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Logged out from all sessions.",
+	}
+
+	return res, nil
+}
+
+func (s *service) VerifyEmail(c context.Context, req *VerifyEmailReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	verification, err := s.authRepo.ConsumeEmailVerification(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return nil, errors.New("invalid or expired verification token")
+	}
+
+	err = s.userRepo.MarkEmailVerified(ctx, int(verification.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Other tokens sent to the user are no longer needed
+	err = s.authRepo.DeleteEmailVerifications(ctx, int(verification.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Email verified.",
+	}
+
+	return res, nil
+}
+
+func (s *service) ResendVerification(c context.Context, req *ResendVerificationReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Same response whether or not the email belongs to an unverified user
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "If the email belongs to an unverified account, a verification email has been sent.",
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || user.EmailVerifiedAt != nil {
+		return res, nil
+	}
+
+	// Sent in the background and failures are only logged, so neither the response time nor an error
+	// tells the email belongs to an unverified account
+	go s.sendDetached(fmt.Sprintf("verification email to user %d", user.ID), func(ctx context.Context) error {
+		if err := s.authRepo.DeleteEmailVerifications(ctx, int(user.ID)); err != nil {
+			return err
+		}
+		return s.sendEmailVerification(ctx, user)
+	})
+
+	return res, nil
+}
+
+func (s *service) ForgotPassword(c context.Context, req *ForgotPasswordReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Same response whether or not the email exists, to prevent account enumeration
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "If the email belongs to an account, a password reset email has been sent.",
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return res, nil
+	}
+
+	// Sent in the background and failures are only logged, so neither the response time nor an error tells the email exists
+	go s.sendDetached(fmt.Sprintf("password reset email to user %d", user.ID), func(ctx context.Context) error {
+		return s.sendPasswordReset(ctx, user)
 	})
 
+	return res, nil
+}
+
+func (s *service) ResetPassword(c context.Context, req *ResetPasswordReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	reset, err := s.authRepo.ConsumePasswordReset(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return nil, errors.New("invalid or expired reset token")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	res := &LoginRes{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	err = s.userRepo.ChangePassword(ctx, int(reset.UserID), hashedPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.authRepo.DeletePasswordResets(ctx, int(reset.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Whoever knew the old password must not keep a session
+	familyIDs, err := s.authRepo.DeleteByUserID(ctx, int(reset.UserID))
+	if err != nil {
+		return nil, err
+	}
+	revokeSessions(familyIDs...)
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Password reset, login with the new password.",
 	}
 
 	return res, nil
 }
 
-func (s *service) RefreshToken(c context.Context, req *RefreshTokenReq) (*RefreshTokenRes, error) {
+func (s *service) LoginMFA(c context.Context, req *LoginMFAReq) (*LoginRes, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	refreshToken, err := s.authRepo.FindByToken(ctx, req.RefreshToken)
+	claims, err := utils.ValidateToken(req.MFAToken)
+	if err != nil || claims.Type != utils.MFAPendingTokenType {
+		return nil, errors.New("invalid mfa token")
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, errors.New("invalid mfa token")
+	}
+
+	// The token allows a few guesses only, and once the login completes it can't be used again
+	challenge, err := s.authRepo.UseMFAChallengeAttempt(ctx, claims.Id, mfaMaxAttempts)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && challenge.UserID != int64(userID)) {
+		return nil, errors.New("invalid or expired mfa token, login again")
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.userRepo.GetByID(ctx, int(refreshToken.UserID))
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Throttled like the password, so new mfa tokens don't allow guessing the code any faster
+	accountKey := accountThrottleKey(user.Email)
+	ipKey := ipThrottleKey(req.IP)
+	if err := s.checkLoginThrottle(ctx, accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, userID, req.Code); err != nil {
+		s.recordLoginFailure(ctx, accountKey, &user.ID, req.IP, config.AppConfig.LoginMaxFailures, eventAccountLocked)
+		s.recordLoginFailure(ctx, ipKey, &user.ID, req.IP, config.AppConfig.LoginIPMaxFailures, eventIPLocked)
+
+		return nil, err
+	}
+
+	err = s.authRepo.ClearLoginThrottle(ctx, accountKey)
 	if err != nil {
 		return nil, err
 	}
 
-	newAccessToken, err := utils.GenerateToken(user.ID, s.secret, time.Minute*15)
+	// Completed by a concurrent request with the same token
+	deleted, err := s.authRepo.DeleteMFAChallenge(ctx, challenge.ID)
 	if err != nil {
 		return nil, err
 	}
+	if !deleted {
+		return nil, errors.New("invalid or expired mfa token, login again")
+	}
 
-	res := &RefreshTokenRes{
-		AccessToken: newAccessToken,
+	return s.startSession(ctx, user, req.UserAgent, req.IP, req.DeviceLabel)
+}
+
+func (s *service) EnrollMFA(c context.Context, userID int) (*EnrollMFARes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaEnabled, err := s.mfaEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.authRepo.SaveMFA(ctx, &MFA{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &EnrollMFARes{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(config.AppConfig.MFAIssuer, user.Email, secret),
+	}
+
+	return res, nil
+}
+
+func (s *service) ConfirmMFA(c context.Context, req *ConfirmMFAReq) (*ConfirmMFARes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	mfa, err := s.authRepo.FindMFA(ctx, int(req.ID))
+	if err != nil {
+		return nil, errors.New("two-factor enrollment not started")
+	}
+	if mfa.ConfirmedAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	step, ok := utils.ValidateTOTP(mfa.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+
+	used, err := s.authRepo.UseMFAStep(ctx, int(req.ID), step)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, errors.New("invalid code")
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	codeHashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRandomString(5)
+		if err != nil {
+			return nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, code[:5]+"-"+code[5:])
+		codeHashes = append(codeHashes, utils.HashToken(code))
+	}
+
+	err = s.authRepo.SaveRecoveryCodes(ctx, int(req.ID), codeHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.authRepo.ConfirmMFA(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ConfirmMFARes{
+		RecoveryCodes: recoveryCodes,
 	}
 
 	return res, nil
 }
+
+func (s *service) DisableMFA(c context.Context, req *DisableMFAReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Disabling needs both factors again, a stolen access token alone isn't enough
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkCredentials(ctx, user, req.IP, func() error {
+		// Both factors are checked before failing, so the error doesn't tell whether the password was right
+		passwordOK := utils.CheckPasswordHash(req.Password, user.Password)
+		codeErr := s.verifySecondFactor(ctx, int(user.ID), req.Code)
+		if !passwordOK || codeErr != nil {
+			return errors.New("invalid credentials")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.authRepo.DeleteMFA(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Two-factor authentication disabled.",
+	}
+
+	return res, nil
+}
+
+func (s *service) UnlockUser(c context.Context, req *UnlockUserReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.authRepo.ClearLoginThrottle(ctx, accountThrottleKey(user.Email))
+	if err != nil {
+		return nil, err
+	}
+
+	s.saveAuthEvent(ctx, &AuthEvent{
+		UserID:  &user.ID,
+		Event:   eventAccountUnlocked,
+		Details: fmt.Sprintf("unlocked by admin %d", req.AdminID),
+	})
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "User unlocked.",
+	}
+
+	return res, nil
+}
+
+func (s *service) SuspendUser(c context.Context, req *SuspendUserReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepo.SetSuspended(ctx, int(user.ID), true)
+	if err != nil {
+		return nil, err
+	}
+
+	familyIDs, err := s.authRepo.DeleteByUserID(ctx, int(user.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	revokeSessions(familyIDs...)
+
+	s.saveAuthEvent(ctx, &AuthEvent{
+		UserID:  &user.ID,
+		Event:   eventSuspended,
+		Details: fmt.Sprintf("suspended by admin %d: %s", req.AdminID, req.Reason),
+	})
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "User suspended.",
+	}
+
+	return res, nil
+}
+
+func (s *service) UnsuspendUser(c context.Context, req *SuspendUserReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepo.SetSuspended(ctx, int(user.ID), false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.saveAuthEvent(ctx, &AuthEvent{
+		UserID:  &user.ID,
+		Event:   eventUnsuspended,
+		Details: fmt.Sprintf("unsuspended by admin %d: %s", req.AdminID, req.Reason),
+	})
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "User unsuspended.",
+	}
+
+	return res, nil
+}
+
+func (s *service) ListSessions(c context.Context, userID int, currentSessionID string) ([]*Session, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	sessions, err := s.authRepo.FindSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (s *service) RevokeSession(c context.Context, userID int, sessionID string) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	deleted, err := s.authRepo.DeleteSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrSessionNotFound
+	}
+
+	revokeSessions(sessionID)
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Session revoked.",
+	}
+
+	return res, nil
+}
+
+func (s *service) StartOIDCLogin(c context.Context, providerName string) (*OIDCLoginRes, error) {
+	// The calls to the provider take longer than the queries
+	ctx, cancel := context.WithTimeout(c, s.timeout+oidcRequestTimeout)
+	defer cancel()
+
+	provider, ok := s.oidcProviders[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	state, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	// The details of a provider failure are logged, they mean nothing to the user
+	authURL, err := provider.authCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		log.Printf("Failed to start the login with %s: %v", providerName, err)
+		return nil, ErrProviderUnavailable
+	}
+
+	_, err = s.authRepo.SaveOIDCState(ctx, &OIDCState{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(oidcStateExpiry),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OIDCLoginRes{
+		AuthURL: authURL,
+		State:   state,
+	}
+
+	return res, nil
+}
+
+func (s *service) OIDCCallback(c context.Context, req *OIDCCallbackReq) (*LoginRes, error) {
+	// The calls to the provider take longer than the queries
+	ctx, cancel := context.WithTimeout(c, s.timeout+oidcRequestTimeout)
+	defer cancel()
+
+	provider, ok := s.oidcProviders[req.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	// The state must come back to the browser which started the login
+	if subtle.ConstantTimeCompare([]byte(req.State), []byte(req.BrowserState)) != 1 {
+		return nil, errors.New("invalid login state")
+	}
+
+	state, err := s.authRepo.ConsumeOIDCState(ctx, utils.HashToken(req.State))
+	if err != nil || state.Provider != req.Provider {
+		return nil, errors.New("invalid or expired login state")
+	}
+
+	// The details of a provider failure are logged, they mean nothing to the user
+	claims, err := provider.identity(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("Failed to complete the login with %s: %v", req.Provider, err)
+		return nil, ErrProviderLoginFailed
+	}
+
+	u, err := s.resolveOIDCUser(ctx, req.Provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, u, req.UserAgent, req.IP, "")
+}
+
+// resolveOIDCUser returns the user linked to the provider identity, otherwise links the user with the same
+// verified email or provisions a new user.
+func (s *service) resolveOIDCUser(ctx context.Context, provider string, claims *idTokenClaims) (*user.User, error) {
+	identity, err := s.authRepo.FindUserIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		return s.userRepo.GetByID(ctx, int(identity.UserID))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Only an email the provider verified can be trusted for matching the users
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return nil, errors.New("the identity provider didn't share a verified email")
+	}
+
+	u, err := s.userRepo.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Otherwise whoever registered the email first, without owning it, would keep a password on the account
+		if u.EmailVerifiedAt == nil {
+			return nil, errors.New("verify the email of the existing account before logging in with " + provider)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		name := claims.Name
+		if name == "" {
+			name, _, _ = strings.Cut(claims.Email, "@")
+		}
+
+		// Provisioned users have no password until they reset one
+		u, err = s.userRepo.Create(ctx, &user.User{
+			Name:  name,
+			Email: claims.Email,
+			Role:  "user",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, int(u.ID)); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	default:
+		return nil, err
+	}
+
+	_, err = s.authRepo.SaveUserIdentity(ctx, &UserIdentity{
+		UserID:   u.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (s *service) SendMagicLink(c context.Context, req *MagicLinkReq) (*MagicLinkRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// The nonce binds the link to this device, it's given out whether or not the email exists
+	nonce, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same response whether or not the email exists, to prevent account enumeration
+	res := &MagicLinkRes{
+		Success: true,
+		Message: "If the email belongs to an account, a login link has been sent.",
+		Nonce:   nonce,
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || !slices.Contains(config.AppConfig.MagicLinkRoles, user.Role) || user.SuspendedAt != nil {
+		return res, nil
+	}
+
+	// Sent in the background and failures are only logged, so neither the response time nor an error tells the email exists
+	go s.sendDetached(fmt.Sprintf("login link to user %d", user.ID), func(ctx context.Context) error {
+		return s.sendMagicLink(ctx, user, nonce, req.DeviceLabel)
+	})
+
+	return res, nil
+}
+
+func (s *service) ConsumeMagicLink(c context.Context, req *ConsumeMagicLinkReq) (*LoginRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Consumed before checking the nonce, so a link opened on another device can't be tried again
+	magicLink, err := s.authRepo.ConsumeMagicLink(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return nil, errors.New("invalid or expired login link")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(req.Nonce)), []byte(magicLink.NonceHash)) != 1 {
+		return nil, errors.New("the login link must be opened on the device which asked for it")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, int(magicLink.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	// The role may have changed since the link was sent
+	if !slices.Contains(config.AppConfig.MagicLinkRoles, user.Role) {
+		return nil, errors.New("login links are disabled for the role of the user")
+	}
+
+	// Opening the mailed link proves the ownership of the email
+	if user.EmailVerifiedAt == nil {
+		if err := s.userRepo.MarkEmailVerified(ctx, int(user.ID)); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return s.completeLogin(ctx, user, req.UserAgent, req.IP, magicLink.DeviceLabel)
+}
+
+func (s *service) SendPhoneVerification(c context.Context, req *SendPhoneVerificationReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	phone := req.Phone
+	if phone == "" || phone == user.Phone {
+		if user.Phone == "" {
+			return nil, errors.New("no phone number to verify")
+		}
+		if user.PhoneVerifiedAt != nil {
+			return nil, errors.New("phone number already verified")
+		}
+		phone = user.Phone
+	} else if other, err := s.userRepo.GetByPhone(ctx, phone); err == nil && other.ID != user.ID {
+		return nil, ErrPhoneTaken
+	}
+
+	if err := s.sendPhoneOTP(ctx, user, phone, otpPurposeVerifyPhone); err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Verification code sent.",
+	}
+
+	return res, nil
+}
+
+func (s *service) VerifyPhone(c context.Context, req *VerifyPhoneReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	otp, err := s.checkPhoneOTP(ctx, int(req.ID), otpPurposeVerifyPhone, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	// The code only proves the phone it was texted to, which becomes the user's phone
+	err = s.userRepo.MarkPhoneVerified(ctx, int(req.ID), otp.Phone)
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrPhoneTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if otp.Phone != user.Phone {
+		s.saveAuthEvent(ctx, &AuthEvent{
+			UserID:  &user.ID,
+			Event:   eventPhoneChanged,
+			Details: fmt.Sprintf("user %d changed phone to %s", user.ID, otp.Phone),
+		})
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Phone number verified.",
+	}
+
+	return res, nil
+}
+
+func (s *service) SendPhoneLoginOTP(c context.Context, req *PhoneOTPReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Same response whether or not the phone exists, to prevent account enumeration
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "If the phone number belongs to an account, a login code has been sent.",
+	}
+
+	user, err := s.userRepo.GetByPhone(ctx, req.Phone)
+	if err != nil || user.PhoneVerifiedAt == nil || user.SuspendedAt != nil {
+		return res, nil
+	}
+
+	// Sent in the background and failures are only logged, so neither the response time nor an error tells the phone exists
+	go s.sendDetached(fmt.Sprintf("login code to user %d", user.ID), func(ctx context.Context) error {
+		return s.sendPhoneOTP(ctx, user, user.Phone, otpPurposeLogin)
+	})
+
+	return res, nil
+}
+
+func (s *service) PhoneLogin(c context.Context, req *PhoneLoginReq) (*LoginRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Guessing is bounded per code, the IP throttle bounds it across phones
+	ipKey := ipThrottleKey(req.IP)
+	if err := s.checkLoginThrottle(ctx, ipKey); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByPhone(ctx, req.Phone)
+	if err == nil && user.PhoneVerifiedAt != nil {
+		_, err = s.checkPhoneOTP(ctx, int(user.ID), otpPurposeLogin, req.Code)
+	} else if err == nil {
+		err = errors.New("phone number not verified")
+	}
+	if err != nil {
+		var userID *int64
+		if user != nil {
+			userID = &user.ID
+		}
+		s.recordLoginFailure(ctx, ipKey, userID, req.IP, config.AppConfig.LoginIPMaxFailures, eventIPLocked)
+
+		return nil, errors.New("invalid phone number or code")
+	}
+
+	return s.completeLogin(ctx, user, req.UserAgent, req.IP, req.DeviceLabel)
+}
+
+func (s *service) ChangeEmail(c context.Context, req *ChangeEmailReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	// A stolen access token alone mustn't be enough to take over the account
+	err = s.checkCredentials(ctx, user, req.IP, func() error {
+		if !utils.CheckPasswordHash(req.Password, user.Password) {
+			return errors.New("invalid credentials")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(req.NewEmail, user.Email) {
+		return nil, errors.New("new email is the current email")
+	}
+	if _, err := s.userRepo.GetByEmail(ctx, req.NewEmail); err == nil {
+		return nil, ErrEmailTaken
+	}
+
+	if err := s.sendEmailChange(ctx, user, req.NewEmail); err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Confirmation link sent to the new email.",
+	}
+
+	return res, nil
+}
+
+func (s *service) ConfirmEmailChange(c context.Context, req *EmailChangeTokenReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	change, err := s.authRepo.ConfirmEmailChange(ctx, utils.HashToken(req.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("invalid or expired confirmation token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.saveAuthEvent(ctx, &AuthEvent{
+		UserID:  &change.UserID,
+		Event:   eventEmailChanged,
+		Details: fmt.Sprintf("user %d changed email to %s", change.UserID, change.NewEmail),
+	})
+
+	// The sessions were started with the old email
+	familyIDs, err := s.authRepo.DeleteByUserID(ctx, int(change.UserID))
+	if err != nil {
+		return nil, err
+	}
+	revokeSessions(familyIDs...)
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Email changed, login with the new email.",
+	}
+
+	return res, nil
+}
+
+func (s *service) CancelEmailChange(c context.Context, req *EmailChangeTokenReq) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	_, err := s.authRepo.CancelEmailChange(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return nil, errors.New("invalid cancel token or the change is already done")
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Email change cancelled.",
+	}
+
+	return res, nil
+}
+
+func (s *service) ImpersonateUser(c context.Context, req *ImpersonateReq) (*ImpersonateRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if req.ID == req.AdminID {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	userRole, err := s.roleRepo.GetByName(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins can't act as each other, which would hide who did what
+	if slices.Contains(userRole.Permissions, "users:impersonate") {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	accessToken, err := utils.GenerateImpersonationToken(user.ID, user.Role, userRole.Permissions, req.AdminID, impersonationExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	s.saveAuthEvent(ctx, &AuthEvent{
+		UserID:  &user.ID,
+		Event:   eventImpersonated,
+		IP:      req.IP,
+		Details: fmt.Sprintf("impersonated by admin %d: %s", req.AdminID, req.Reason),
+	})
+
+	res := &ImpersonateRes{
+		AccessToken: accessToken,
+		ExpiresAt:   time.Now().Add(impersonationExpiry),
+	}
+
+	return res, nil
+}
+
+func (s *service) RecordImpersonatedRequest(c context.Context, audit *ImpersonationAudit) (*ImpersonationAudit, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.authRepo.SaveImpersonationAudit(ctx, audit)
+}
+
+func (s *service) RecordImpersonatedStatus(c context.Context, auditID int64, status int) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.authRepo.SetImpersonationAuditStatus(ctx, auditID, status)
+}
+
+func (s *service) Reauthenticate(c context.Context, req *ReauthenticateReq) (*ReauthenticateRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByID(ctx, int(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkCredentials(ctx, user, req.IP, func() error {
+		if req.Password != "" {
+			if !utils.CheckPasswordHash(req.Password, user.Password) {
+				return errors.New("invalid credentials")
+			}
+			return nil
+		}
+
+		return s.verifySecondFactor(ctx, int(user.ID), req.Code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if user.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	// Stored on the session, so the refreshed access tokens stay recent as well
+	authTime, err := s.authRepo.MarkSessionAuthenticated(ctx, int(user.ID), req.SessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	userRole, err := s.roleRepo.GetByName(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateToken(user.ID, user.Role, userRole.Permissions, req.SessionID, authTime, accessTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReauthenticateRes{
+		AccessToken: accessToken,
+	}
+
+	return res, nil
+}
+
+func (s *service) CheckPassword(c context.Context, u *user.User, password string, ip string) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	err := s.checkCredentials(ctx, u, ip, func() error {
+		if !utils.CheckPasswordHash(password, u.Password) {
+			return user.ErrPasswordMismatch
+		}
+		return nil
+	})
+	if errors.Is(err, ErrLoginThrottled) {
+		return user.ErrTooManyAttempts
+	}
+
+	return err
+}
+
+func (s *service) RevokeUserSessions(c context.Context, userID int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	familyIDs, err := s.authRepo.DeleteByUserID(ctx, int(userID))
+	if err != nil {
+		return err
+	}
+	revokeSessions(familyIDs...)
+
+	return nil
+}
+
+// rehashPassword stores the password hashed with the current hasher in place of the verified hash, it runs after
+// the login responded so the request context can't be used. A password changed meanwhile is kept.
+func (s *service) rehashPassword(userID int64, password string, verifiedHash string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.Printf("Failed to rehash password of user %d: %v", userID, err)
+		return
+	}
+
+	if _, err := s.userRepo.ReplacePasswordHash(ctx, int(userID), verifiedHash, hashedPassword); err != nil {
+		log.Printf("Failed to store rehashed password of user %d: %v", userID, err)
+	}
+}
+
+// sendDetached runs the send of a token which must not tell whether the account exists, after the response so
+// the request context can't be used. Failures are only logged.
+func (s *service) sendDetached(what string, send func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	if err := send(ctx); err != nil {
+		log.Printf("Failed to send %s: %v", what, err)
+	}
+}
+
+// checkCredentials runs the check of the credentials of the user for a sensitive operation, throttled like the logins
+// else a stolen access token would allow guessing the password.
+func (s *service) checkCredentials(ctx context.Context, u *user.User, ip string, check func() error) error {
+	accountKey := accountThrottleKey(u.Email)
+	ipKey := ipThrottleKey(ip)
+	if err := s.checkLoginThrottle(ctx, accountKey, ipKey); err != nil {
+		return err
+	}
+
+	if err := check(); err != nil {
+		s.recordLoginFailure(ctx, accountKey, &u.ID, ip, config.AppConfig.LoginMaxFailures, eventAccountLocked)
+		s.recordLoginFailure(ctx, ipKey, &u.ID, ip, config.AppConfig.LoginIPMaxFailures, eventIPLocked)
+
+		return err
+	}
+
+	return s.authRepo.ClearLoginThrottle(ctx, accountKey)
+}
+
+// checkLoginThrottle fails when any of the keys is locked or still waiting out the backoff of its last failure.
+func (s *service) checkLoginThrottle(ctx context.Context, keys ...string) error {
+	now := time.Now()
+	for _, key := range keys {
+		throttle, err := s.authRepo.FindLoginThrottle(ctx, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+			return ErrLoginThrottled
+		}
+
+		if now.Before(throttle.LastFailedAt.Add(loginBackoff(throttle.FailedCount))) {
+			return ErrLoginThrottled
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts a failed login for the key and locks it once maxFailures is reached.
+// Errors are only logged, so the response stays the same as for any other failed login.
+func (s *service) recordLoginFailure(ctx context.Context, key string, userID *int64, ip string, maxFailures int, lockEvent string) {
+	lockout := time.Duration(config.AppConfig.LoginLockoutMinutes) * time.Minute
+
+	throttle, err := s.authRepo.RecordLoginFailure(ctx, key, lockout)
+	if err != nil {
+		log.Printf("Failed to record failed login for %s: %v", key, err)
+		return
+	}
+
+	if throttle.FailedCount < maxFailures {
+		return
+	}
+
+	if err := s.authRepo.LockLogin(ctx, key, time.Now().Add(lockout)); err != nil {
+		log.Printf("Failed to lock logins for %s: %v", key, err)
+		return
+	}
+
+	// Only the account lockout belongs to a user, an IP lockout spans every account tried from it
+	if lockEvent != eventAccountLocked {
+		userID = nil
+	}
+
+	s.saveAuthEvent(ctx, &AuthEvent{
+		UserID:  userID,
+		Event:   lockEvent,
+		IP:      ip,
+		Details: fmt.Sprintf("%s locked for %s after %d failed logins", key, lockout, throttle.FailedCount),
+	})
+}
+
+// saveAuthEvent logs the security event and stores it, a failed store is only logged.
+func (s *service) saveAuthEvent(ctx context.Context, event *AuthEvent) {
+	log.Printf("Auth event %s: %s", event.Event, event.Details)
+
+	if _, err := s.authRepo.SaveAuthEvent(ctx, event); err != nil {
+		log.Printf("Failed to save auth event %s: %v", event.Event, err)
+	}
+}
+
+// loginBackoff returns the wait after the given number of failed logins, doubling with each failure.
+func loginBackoff(failedCount int) time.Duration {
+	if failedCount <= 0 {
+		return 0
+	}
+
+	backoff := time.Second
+	for i := 1; i < failedCount && backoff < maxLoginBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxLoginBackoff)
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// mfaEnabled reports whether the user has a confirmed second factor.
+func (s *service) mfaEnabled(ctx context.Context, userID int) (bool, error) {
+	mfa, err := s.authRepo.FindMFA(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return mfa.ConfirmedAt != nil, nil
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code of the user, each only once.
+func (s *service) verifySecondFactor(ctx context.Context, userID int, code string) error {
+	mfa, err := s.authRepo.FindMFA(ctx, userID)
+	if err != nil || mfa.ConfirmedAt == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now()); ok {
+		used, err := s.authRepo.UseMFAStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+
+		return errors.New("invalid code")
+	}
+
+	// Recovery codes are accepted with or without the dash and in any case
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	used, err := s.authRepo.UseRecoveryCode(ctx, userID, utils.HashToken(normalized))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.New("invalid code")
+	}
+
+	return nil
+}
+
+// sendPasswordReset stores a new password reset token for the user and mails it.
+func (s *service) sendPasswordReset(ctx context.Context, u *user.User) error {
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.authRepo.SavePasswordReset(ctx, &PasswordReset{
+		UserID:    u.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetExpiry),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.AppConfig.AppURL, url.QueryEscape(token))
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nReset your password by opening the link below, it expires in 1 hour. If you didn't ask for it, ignore this email.\n\n%s\n\nReset token: %s",
+			u.Name, link, token,
+		),
+	})
+}
+
+// sendMagicLink stores a new login link token for the user, bound to the device nonce, and mails it.
+func (s *service) sendMagicLink(ctx context.Context, u *user.User, nonce, deviceLabel string) error {
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	expiry := time.Duration(config.AppConfig.MagicLinkMinutes) * time.Minute
+	_, err = s.authRepo.SaveMagicLink(ctx, &MagicLink{
+		UserID:      u.ID,
+		TokenHash:   utils.HashToken(token),
+		NonceHash:   utils.HashToken(nonce),
+		DeviceLabel: deviceLabel,
+		ExpiresAt:   time.Now().Add(expiry),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/magic-link?token=%s", config.AppConfig.AppURL, url.QueryEscape(token))
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      u.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nLog in by opening the link below on the device you asked for it from, it expires in %d minutes and works once. If you didn't ask for it, ignore this email.\n\n%s",
+			u.Name, config.AppConfig.MagicLinkMinutes, link,
+		),
+	})
+}
+
+// sendPhoneOTP stores a new phone code for the user and the purpose and texts it to the phone.
+func (s *service) sendPhoneOTP(ctx context.Context, u *user.User, phone string, purpose string) error {
+	code, err := utils.GenerateNumericCode(otpDigits)
+	if err != nil {
+		return err
+	}
+
+	expiry := time.Duration(config.AppConfig.OTPMinutes) * time.Minute
+	cooldown := time.Duration(config.AppConfig.OTPResendSeconds) * time.Second
+	saved, err := s.authRepo.SavePhoneOTP(ctx, &PhoneOTP{
+		UserID:    u.ID,
+		Purpose:   purpose,
+		Phone:     phone,
+		CodeHash:  phoneOTPHash(phone, code),
+		ExpiresAt: time.Now().Add(expiry),
+	}, time.Now().Add(-cooldown))
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrOTPCooldown
+	}
+
+	return s.smsSender.Send(ctx, &sms.Message{
+		To:   phone,
+		Body: fmt.Sprintf("Your code is %s, it expires in %d minutes. Don't share it with anyone.", code, config.AppConfig.OTPMinutes),
+	})
+}
+
+// checkPhoneOTP counts a guess on the pending phone code of the user for the purpose and consumes it if the code matches.
+func (s *service) checkPhoneOTP(ctx context.Context, userID int, purpose, code string) (*PhoneOTP, error) {
+	otp, err := s.authRepo.UsePhoneOTPAttempt(ctx, userID, purpose, config.AppConfig.OTPMaxAttempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("invalid or expired code, ask for a new one")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(phoneOTPHash(otp.Phone, code)), []byte(otp.CodeHash)) != 1 {
+		return nil, errors.New("invalid code")
+	}
+
+	// Deleted by a concurrent request with the same code
+	deleted, err := s.authRepo.DeletePhoneOTP(ctx, int(otp.ID))
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, errors.New("invalid or expired code, ask for a new one")
+	}
+
+	return otp, nil
+}
+
+// phoneOTPHash returns the digest of a phone code, salted with the phone it was texted to.
+func phoneOTPHash(phone, code string) string {
+	return utils.HashToken(phone + ":" + code)
+}
+
+// sendEmailChange stores a new email change for the user, mails the confirmation link to the new email
+// and the notice with the cancel link to the old one.
+func (s *service) sendEmailChange(ctx context.Context, u *user.User, newEmail string) error {
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+	cancelToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.authRepo.SaveEmailChange(ctx, &EmailChange{
+		UserID:          u.ID,
+		NewEmail:        newEmail,
+		TokenHash:       utils.HashToken(token),
+		CancelTokenHash: utils.HashToken(cancelToken),
+		ExpiresAt:       time.Now().Add(emailChangeExpiry),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/confirm-email-change?token=%s", config.AppConfig.AppURL, url.QueryEscape(token))
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm %s as the new email of your account by opening the link below, it expires in 24 hours. You'll have to login again afterwards.\n\n%s",
+			u.Name, newEmail, link,
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	cancelLink := fmt.Sprintf("%s/cancel-email-change?token=%s", config.AppConfig.AppURL, url.QueryEscape(cancelToken))
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      u.Email,
+		Subject: "Your email is about to change",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nA change of the email of your account to %s was requested. If it wasn't you, cancel it by opening the link below and change your password.\n\n%s",
+			u.Name, newEmail, cancelLink,
+		),
+	})
+}
+
+// sendEmailVerification stores a new email verification token for the user and mails it.
+func (s *service) sendEmailVerification(ctx context.Context, u *user.User) error {
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.authRepo.SaveEmailVerification(ctx, &EmailVerification{
+		UserID:    u.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(emailVerificationExpiry),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.AppConfig.AppURL, url.QueryEscape(token))
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      u.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your email address by opening the link below, it expires in 24 hours.\n\n%s\n\nVerification token: %s",
+			u.Name, link, token,
+		),
+	})
+}
+
+// completeLogin applies the login policies once the user proved the first factor, and starts a session
+// or asks for the second factor.
+func (s *service) completeLogin(ctx context.Context, u *user.User, userAgent, ip, label string) (*LoginRes, error) {
+	if u.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	if config.AppConfig.RequireEmailVerification && u.EmailVerifiedAt == nil {
+		return nil, errors.New("email not verified")
+	}
+
+	// With a second factor the first one only earns a short lived token for the next step
+	mfaEnabled, err := s.mfaEnabled(ctx, int(u.ID))
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		mfaToken, challengeID, err := utils.GenerateMFAToken(u.ID, mfaTokenExpiry)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.authRepo.SaveMFAChallenge(ctx, &MFAChallenge{
+			ID:        challengeID,
+			UserID:    u.ID,
+			ExpiresAt: time.Now().Add(mfaTokenExpiry),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		res := &LoginRes{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}
+
+		return res, nil
+	}
+
+	return s.startSession(ctx, u, userAgent, ip, label)
+}
+
+// startSession records a new session for the device of a login, the session id starts a new refresh token family.
+func (s *service) startSession(ctx context.Context, u *user.User, userAgent, ip, label string) (*LoginRes, error) {
+	familyID, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	if label == "" {
+		label = deviceLabel(userAgent)
+	}
+
+	_, err = s.authRepo.SaveSession(ctx, &Session{
+		ID:          familyID,
+		UserID:      u.ID,
+		UserAgent:   truncate(userAgent, 512),
+		IP:          ip,
+		DeviceLabel: label,
+		ExpiresAt:   time.Now().Add(refreshTokenExpiry),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, u, familyID)
+}
+
+// issueTokens stores a new refresh token in the given family and pairs it with an access token.
+func (s *service) issueTokens(ctx context.Context, u *user.User, familyID string) (*LoginRes, error) {
+	// Guards every way of getting tokens, like refreshing a token issued before the suspension
+	if u.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	// Refresh tokens are opaque random values, only their digest is stored
+	refreshToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session lasts as long as its latest refresh token
+	expiresAt := time.Now().Add(refreshTokenExpiry)
+	authTime, err := s.authRepo.TouchSession(ctx, familyID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.authRepo.Save(ctx, &RefreshToken{
+		UserID:    u.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Permissions are read on every issue, so a role change applies from the next refresh
+	userRole, err := s.roleRepo.GetByName(ctx, u.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	// A refresh keeps the auth time of the session, only proving the credentials again renews it
+	accessToken, err := utils.GenerateToken(u.ID, u.Role, userRole.Permissions, familyID, authTime, accessTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &LoginRes{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+
+	return res, nil
+}
+
+// revokeSessions denylists the access tokens issued from the given refresh token families
+// for as long as those access tokens could still be valid.
+func revokeSessions(familyIDs ...string) {
+	for _, familyID := range familyIDs {
+		utils.RevokedSessions.Add(familyID, accessTokenExpiry)
+	}
+}
+
+// deviceLabel derives a readable label like "Firefox on Linux" from the user agent.
+func deviceLabel(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	// The order matters, as most user agents mention the browsers they are based on
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	systems := []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+
+	browser := "Unknown browser"
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, system := range systems {
+		if strings.Contains(userAgent, system.token) {
+			return browser + " on " + system.name
+		}
+	}
+
+	return browser
+}
+
+// truncate shortens the string to at most n bytes, cutting on a character boundary so it stays valid UTF-8.
+func truncate(s string, n int) string {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
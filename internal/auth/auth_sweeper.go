@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+// Sweeper periodically purges the expired sessions, refresh tokens and pending logins, which nothing else removes.
+type Sweeper struct {
+	authRepo Repository
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// NewSweeper initialize and return the Sweeper
+func NewSweeper(ar Repository, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		authRepo: ar,
+		interval: interval,
+		timeout:  time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+// Run purges the expired rows right away and then on every interval, until the context is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sweeper) sweep(c context.Context) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	deleted, err := s.authRepo.DeleteExpired(ctx)
+	if err != nil {
+		log.Printf("Failed to purge expired rows: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("Purged %d expired sessions, refresh tokens and pending logins", deleted)
+	}
+}
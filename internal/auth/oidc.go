@@ -0,0 +1,524 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+const (
+	// oidcRequestTimeout bounds every call to a provider
+	oidcRequestTimeout = 10 * time.Second
+	// oidcKeysRefreshInterval limits how often the provider keys are fetched again for an unknown kid
+	oidcKeysRefreshInterval = time.Minute
+	// oidcTypeOAuth2 is the type of the plain OAuth 2.0 providers, which have neither discovery nor ID tokens
+	oidcTypeOAuth2 = "oauth2"
+)
+
+// oidcProvider is an OpenID Connect provider, its metadata and signing keys are fetched on first use and cached.
+// A plain OAuth 2.0 provider has its endpoints configured and no signing keys.
+type oidcProvider struct {
+	config     config.OIDCProvider
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	metadata      *oidcMetadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// oidcMetadata holds the discovered provider endpoints.
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenRes holds the tokens returned by the token endpoint.
+type oidcTokenRes struct {
+	IDToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// oauth2UserInfo holds the user returned by the userinfo endpoint of a plain OAuth 2.0 provider,
+// identified by either the standard sub claim or an id like GitHub's.
+type oauth2UserInfo struct {
+	Subject       string   `json:"sub"`
+	ID            any      `json:"id"`
+	Email         string   `json:"email"`
+	EmailVerified oidcBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	Login         string   `json:"login"`
+}
+
+// oauth2Email is an email of the user returned by the emails endpoint of a plain OAuth 2.0 provider, like GitHub's.
+type oauth2Email struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// oidcJWK is a public key published by a provider, only RSA and EC keys are used for ID tokens.
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// idTokenClaims holds the claims of an ID token, they are checked by verifyIDToken.
+type idTokenClaims struct {
+	Issuer          string      `json:"iss"`
+	Subject         string      `json:"sub"`
+	Audience        oidcStrings `json:"aud"`
+	AuthorizedParty string      `json:"azp"`
+	ExpiresAt       int64       `json:"exp"`
+	IssuedAt        int64       `json:"iat"`
+	Nonce           string      `json:"nonce"`
+	Email           string      `json:"email"`
+	EmailVerified   oidcBool    `json:"email_verified"`
+	Name            string      `json:"name"`
+}
+
+// Valid lets the parser skip the registered claims, they are checked against the provider instead.
+func (c *idTokenClaims) Valid() error {
+	return nil
+}
+
+// oidcStrings accepts either a single string or an array of strings, like the aud claim.
+type oidcStrings []string
+
+func (s *oidcStrings) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = oidcStrings{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*s = multiple
+
+	return nil
+}
+
+// oidcBool accepts a boolean sent either as a JSON boolean or as a string, as some providers do.
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = oidcBool(value)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	*b = oidcBool(str == "true")
+
+	return nil
+}
+
+// newOIDCProviders initialize the configured providers by name
+func newOIDCProviders(providerConfigs []config.OIDCProvider) map[string]*oidcProvider {
+	providers := make(map[string]*oidcProvider)
+	for _, providerConfig := range providerConfigs {
+		providers[providerConfig.Name] = &oidcProvider{
+			config:     providerConfig,
+			httpClient: &http.Client{Timeout: oidcRequestTimeout},
+		}
+	}
+
+	return providers
+}
+
+// authCodeURL returns the provider url starting an authorization code flow with PKCE.
+func (p *oidcProvider) authCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// identity redeems the authorization code and returns the claims of the user, verified from the ID token,
+// or read from the userinfo endpoint for a plain OAuth 2.0 provider.
+func (p *oidcProvider) identity(ctx context.Context, code, codeVerifier, nonce string) (*idTokenClaims, error) {
+	tokenRes, err := p.exchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config.Type == oidcTypeOAuth2 {
+		return p.userInfo(ctx, tokenRes.AccessToken)
+	}
+
+	return p.verifyIDToken(ctx, tokenRes.IDToken, nonce)
+}
+
+// exchangeCode redeems the authorization code at the token endpoint and returns the tokens, along with
+// the ID token an OpenID Connect provider must return.
+func (p *oidcProvider) exchangeCode(ctx context.Context, code, codeVerifier string) (*oidcTokenRes, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {p.config.ClientID},
+	}
+
+	// Plain OAuth 2.0 providers like GitHub only take client_secret_post
+	if p.config.Type == oidcTypeOAuth2 {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.Type != oidcTypeOAuth2 {
+		// client_secret_basic, with the credentials form encoded as RFC 6749 asks
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tokenRes oidcTokenRes
+	if err := json.NewDecoder(resp.Body).Decode(&tokenRes); err != nil {
+		return nil, fmt.Errorf("invalid token response from %s: %v", p.config.Name, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || tokenRes.Error != "" {
+		return nil, fmt.Errorf("code exchange with %s failed: %s %s", p.config.Name, tokenRes.Error, tokenRes.ErrorDescription)
+	}
+
+	if p.config.Type == oidcTypeOAuth2 && tokenRes.AccessToken == "" {
+		return nil, fmt.Errorf("no access token returned by %s", p.config.Name)
+	}
+	if p.config.Type != oidcTypeOAuth2 && tokenRes.IDToken == "" {
+		return nil, fmt.Errorf("no id token returned by %s", p.config.Name)
+	}
+
+	return &tokenRes, nil
+}
+
+// userInfo reads the user of the access token from the userinfo endpoint of a plain OAuth 2.0 provider.
+// The email is only trusted as verified if the provider says so, or lists it as the verified primary one
+// at its emails endpoint.
+func (p *oidcProvider) userInfo(ctx context.Context, accessToken string) (*idTokenClaims, error) {
+	var info oauth2UserInfo
+	if err := p.getJSON(ctx, p.config.UserInfoURL, accessToken, &info); err != nil {
+		return nil, fmt.Errorf("fetching the user from %s failed: %v", p.config.Name, err)
+	}
+
+	claims := &idTokenClaims{
+		Subject:       info.Subject,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+	}
+	if claims.Subject == "" && info.ID != nil {
+		claims.Subject = fmt.Sprint(info.ID)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("the user from %s has no id", p.config.Name)
+	}
+	if claims.Name == "" {
+		claims.Name = info.Login
+	}
+
+	if p.config.EmailsURL != "" {
+		var emails []oauth2Email
+		if err := p.getJSON(ctx, p.config.EmailsURL, accessToken, &emails); err != nil {
+			return nil, fmt.Errorf("fetching the emails from %s failed: %v", p.config.Name, err)
+		}
+
+		claims.Email, claims.EmailVerified = "", false
+		for _, email := range emails {
+			if email.Primary && email.Verified {
+				claims.Email, claims.EmailVerified = email.Email, true
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+// verifyIDToken checks the signature of the ID token against the provider keys along with its
+// issuer, audience, timestamps and nonce, and returns its claims.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (*idTokenClaims, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &jwt.Parser{
+		ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"},
+	}
+
+	claims := &idTokenClaims{}
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %v", err)
+	}
+
+	now := time.Now().Unix()
+	leeway := int64(config.AppConfig.JWTLeeway)
+
+	switch {
+	case claims.Issuer != metadata.Issuer:
+		return nil, errors.New("invalid id token issuer")
+	case !slices.Contains(claims.Audience, p.config.ClientID):
+		return nil, errors.New("invalid id token audience")
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID:
+		return nil, errors.New("invalid id token authorized party")
+	case claims.ExpiresAt == 0 || now-leeway > claims.ExpiresAt:
+		return nil, errors.New("id token is expired")
+	case now+leeway < claims.IssuedAt:
+		return nil, errors.New("id token is not valid yet")
+	case subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		return nil, errors.New("invalid id token nonce")
+	case claims.Subject == "":
+		return nil, errors.New("id token has no subject")
+	}
+
+	return claims, nil
+}
+
+// getMetadata returns the provider metadata, discovered from the issuer on first use,
+// or the configured endpoints of a plain OAuth 2.0 provider.
+func (p *oidcProvider) getMetadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	if p.config.Type == oidcTypeOAuth2 {
+		if p.config.AuthURL == "" || p.config.TokenURL == "" || p.config.UserInfoURL == "" {
+			return nil, fmt.Errorf("%s needs the auth, token and userinfo urls configured", p.config.Name)
+		}
+
+		p.metadata = &oidcMetadata{
+			AuthorizationEndpoint: p.config.AuthURL,
+			TokenEndpoint:         p.config.TokenURL,
+		}
+
+		return p.metadata, nil
+	}
+
+	var metadata oidcMetadata
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, "", &metadata); err != nil {
+		return nil, fmt.Errorf("discovery of %s failed: %v", p.config.Name, err)
+	}
+
+	// The metadata must be the issuer's own, per OpenID Connect Discovery
+	if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(p.config.Issuer, "/") {
+		return nil, fmt.Errorf("discovery of %s returned another issuer %q", p.config.Name, metadata.Issuer)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery of %s returned incomplete metadata", p.config.Name)
+	}
+
+	p.metadata = &metadata
+
+	return p.metadata, nil
+}
+
+// publicKey returns the provider key by kid, the keys are fetched again when the kid is unknown
+// as the provider may have rotated them.
+func (p *oidcProvider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < oidcKeysRefreshInterval {
+		return nil, errors.New("unknown signing key")
+	}
+
+	var jwks struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, metadata.JWKSURI, "", &jwks); err != nil {
+		return nil, fmt.Errorf("fetching the keys of %s failed: %v", p.config.Name, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Keys of other types can't sign the ID tokens we accept
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+
+	return key, nil
+}
+
+// getJSON fetches and decodes a JSON document, with the access token if given. Numbers are kept as json.Number,
+// so large ids don't lose precision.
+func (p *oidcProvider) getJSON(ctx context.Context, url string, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
+
+// publicKey decodes the RSA or EC public key of the JWK.
+func (k *oidcJWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid EC key")
+		}
+
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
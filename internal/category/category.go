@@ -0,0 +1,45 @@
+package category
+
+import "time"
+
+// Category represents a node of the category tree, like Phones under Electronics.
+// The path lists the ids from the root down to the category, like "1/4/9/", so the descendants share it as a prefix.
+type Category struct {
+	ID          int64       `json:"id"`
+	ParentID    *int64      `json:"parent_id"`
+	Name        string      `json:"name"`
+	Slug        string      `json:"slug"`
+	Description string      `json:"description"`
+	Position    int         `json:"position"`
+	Path        string      `json:"path"`
+	Depth       int         `json:"depth"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Children    []*Category `json:"children,omitempty"`
+}
+
+// CreateCategoryReq represents the request payload for creating a category, a root one without a parent.
+// Without a slug one is derived from the name.
+type CreateCategoryReq struct {
+	ParentID    *int64 `json:"parent_id"`
+	Name        string `json:"name" validate:"required,max=255"`
+	Slug        string `json:"slug" validate:"max=255"`
+	Description string `json:"description" validate:"max=5000"`
+	Position    int    `json:"position"`
+}
+
+// UpdateCategoryReq represents the request payload for updating a category, moving it is done through MoveCategoryReq.
+type UpdateCategoryReq struct {
+	ID          int64  `json:"id" validate:"required"`
+	Name        string `json:"name" validate:"required,max=255"`
+	Slug        string `json:"slug" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=5000"`
+	Position    int    `json:"position"`
+}
+
+// MoveCategoryReq represents the request payload for moving a category along with its subtree under another parent,
+// or to the root without a parent.
+type MoveCategoryReq struct {
+	ID       int64  `json:"id" validate:"required"`
+	ParentID *int64 `json:"parent_id"`
+}
@@ -0,0 +1,293 @@
+package category
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler struct to hold the category service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the category Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// GetTree       godoc
+// @Summary      Get Category Tree
+// @Description  Get the whole category tree, or the subtree of the root, with the children nested
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param        root_id  query  int  false  "Root of the subtree"
+// @Success      200  {array}   Category
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /categories [get]
+func (h *Handler) GetTree(w http.ResponseWriter, r *http.Request) {
+	var rootID *int64
+	if rootIDstr := r.URL.Query().Get("root_id"); rootIDstr != "" {
+		id, err := strconv.ParseInt(rootIDstr, 10, 64)
+		if err != nil {
+			utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		rootID = &id
+	}
+
+	res, err := h.service.GetTree(r.Context(), rootID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetCategory   godoc
+// @Summary      Get Category
+// @Description  Get a category by provided ID in url
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param        category_id  path  int  true  "Category ID"
+// @Success      200  {object}  Category
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /categories/{category_id} [get]
+func (h *Handler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	categoryIDstr := chi.URLParam(r, "category_id")
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetCategory(r.Context(), categoryID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetBreadcrumbs godoc
+// @Summary      Get Category Breadcrumbs
+// @Description  Get the ancestors of a category from the root down, the category included
+// @Tags         Category
+// @Accept       json
+// @Produce      json
+// @Param        category_id  path  int  true  "Category ID"
+// @Success      200  {array}   Category
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /categories/{category_id}/breadcrumbs [get]
+func (h *Handler) GetBreadcrumbs(w http.ResponseWriter, r *http.Request) {
+	categoryIDstr := chi.URLParam(r, "category_id")
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetBreadcrumbs(r.Context(), categoryID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// CreateCategory godoc
+// @Summary      Create Category
+// @Description  Create a category under the parent, or a root one without a parent. Without a slug one is derived from the name
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        body  body  CreateCategoryReq  true  "Category create request"
+// @Success      201  {object}  Category
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /admin/categories [post]
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var createCategoryReq CreateCategoryReq
+	if err := utils.ReadFromRequest(r, &createCategoryReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(createCategoryReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CreateCategory(r.Context(), &createCategoryReq)
+	if errors.Is(err, ErrSlugTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// UpdateCategory godoc
+// @Summary      Update Category
+// @Description  Update a category by provided ID in url and details in body
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        category_id  path  int  true  "Category ID"
+// @Param        body  body  UpdateCategoryReq  true  "Category update request"
+// @Success      200  {object}  Category
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /admin/categories/{category_id} [put]
+func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	categoryIDstr := chi.URLParam(r, "category_id")
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var updateCategoryReq UpdateCategoryReq
+	if err := utils.ReadFromRequest(r, &updateCategoryReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	updateCategoryReq.ID = int64(categoryID)
+
+	if err := utils.Validate.Struct(updateCategoryReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.UpdateCategory(r.Context(), &updateCategoryReq)
+	if errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrSlugTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// MoveCategory  godoc
+// @Summary      Move Category
+// @Description  Move a category along with its subtree under another parent, or to the root without a parent
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        category_id  path  int  true  "Category ID"
+// @Param        body  body  MoveCategoryReq  true  "Category move request"
+// @Success      200  {object}  Category
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /admin/categories/{category_id}/move [post]
+func (h *Handler) MoveCategory(w http.ResponseWriter, r *http.Request) {
+	categoryIDstr := chi.URLParam(r, "category_id")
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var moveCategoryReq MoveCategoryReq
+	if err := utils.ReadFromRequest(r, &moveCategoryReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	moveCategoryReq.ID = int64(categoryID)
+
+	if err := utils.Validate.Struct(moveCategoryReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.MoveCategory(r.Context(), &moveCategoryReq)
+	if errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete Category
+// @Description  Delete a category without subcategories, its products are only unassigned from it
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        category_id  path  int  true  "Category ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /admin/categories/{category_id} [delete]
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryIDstr := chi.URLParam(r, "category_id")
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.DeleteCategory(r.Context(), categoryID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrCategoryHasChildren) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
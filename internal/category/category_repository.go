@@ -0,0 +1,290 @@
+package category
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Repository interface for the category repository
+type Repository interface {
+	// Create stores a new category under its parent and returns the created category.
+	Create(ctx context.Context, category *Category) (*Category, error)
+
+	// GetByID find and returns the category by category id
+	GetByID(ctx context.Context, id int) (*Category, error)
+
+	// Update updates the name, slug, description and position of the category and returns the updated category.
+	Update(ctx context.Context, category *Category) (*Category, error)
+
+	// Delete removes the category, returns ErrCategoryHasChildren if it still has subcategories.
+	Delete(ctx context.Context, id int) error
+
+	// Move moves the category along with its subtree under the parent, or to the root without a parent,
+	// and returns the moved category.
+	Move(ctx context.Context, id int, parentID *int64) (*Category, error)
+
+	// GetSubtree returns the category with every descendant, or the whole forest without a root,
+	// ordered so that every category comes after its parent.
+	GetSubtree(ctx context.Context, rootID *int64) ([]*Category, error)
+
+	// GetBreadcrumbs returns the ancestors of the category from the root down, the category included.
+	GetBreadcrumbs(ctx context.Context, id int) ([]*Category, error)
+}
+
+// selectColumns are the columns read by scanCategory, in its order
+const selectColumns = `c.id, c.parent_id, c.name, c.slug, c.description, c.position, c.path, c.depth, c.created_at, c.updated_at`
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, category *Category) (*Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The parent is locked so a concurrent move of its subtree can't leave the new category with a stale path
+	parentPath := ""
+	depth := 0
+	if category.ParentID != nil {
+		var parentDepth int
+		err = tx.QueryRowContext(ctx, `SELECT path, depth FROM categories WHERE id = $1 FOR SHARE`, *category.ParentID).
+			Scan(&parentPath, &parentDepth)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrParentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		depth = parentDepth + 1
+	}
+
+	// The id is taken first, as it ends the path
+	insertQuery := `WITH new_category AS (SELECT nextval(pg_get_serial_sequence('categories', 'id')) AS id)
+		INSERT INTO categories(id, parent_id, name, slug, description, position, path, depth)
+		SELECT n.id, $1, $2, $3, $4, $5, $6::text || n.id || '/', $7
+		FROM new_category n
+		RETURNING id, path, depth, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, insertQuery,
+		category.ParentID,
+		category.Name,
+		category.Slug,
+		category.Description,
+		category.Position,
+		parentPath,
+		depth,
+	).Scan(&category.ID, &category.Path, &category.Depth, &category.CreatedAt, &category.UpdatedAt)
+
+	if utils.IsForeignKeyViolation(err) {
+		return nil, ErrParentNotFound
+	}
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, tx.Commit()
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*Category, error) {
+	selectQueryByID := `SELECT ` + selectColumns + ` FROM categories c WHERE c.id = $1`
+
+	return scanCategory(r.db.QueryRowContext(ctx, selectQueryByID, id))
+}
+
+func (r *repository) Update(ctx context.Context, category *Category) (*Category, error) {
+	updateQuery := `UPDATE categories SET name = $1, slug = $2, description = $3, position = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 RETURNING parent_id, path, depth, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, updateQuery,
+		category.Name,
+		category.Slug,
+		category.Description,
+		category.Position,
+		category.ID,
+	).Scan(&category.ParentID, &category.Path, &category.Depth, &category.CreatedAt, &category.UpdatedAt)
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int) error {
+	deleteQuery := `DELETE FROM categories WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, id)
+	// The subcategories keep it from being deleted, the products are only unassigned
+	if utils.IsForeignKeyViolation(err) {
+		return ErrCategoryHasChildren
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) Move(ctx context.Context, id int, parentID *int64) (*Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The new parent is locked along with the subtree, so concurrent moves can't make a cycle
+	newPrefix := ""
+	newDepth := 0
+	if parentID != nil {
+		err = tx.QueryRowContext(ctx, `SELECT path, depth + 1 FROM categories WHERE id = $1 FOR UPDATE`, *parentID).
+			Scan(&newPrefix, &newDepth)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrParentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var oldPath string
+	var oldDepth int
+	err = tx.QueryRowContext(ctx, `SELECT path, depth FROM categories WHERE id = $1 FOR UPDATE`, id).Scan(&oldPath, &oldDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	// A category can't be moved under itself or one of its descendants
+	if strings.HasPrefix(newPrefix, oldPath) {
+		return nil, ErrInvalidMove
+	}
+
+	_, err = tx.ExecContext(ctx, `SELECT id FROM categories WHERE path LIKE $1 || '%' FOR UPDATE`, oldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	newPath := newPrefix + strconv.Itoa(id) + "/"
+	moveQuery := `UPDATE categories SET path = $1 || substr(path, length($2) + 1), depth = depth + $3, updated_at = CURRENT_TIMESTAMP
+		WHERE path LIKE $2 || '%'`
+
+	_, err = tx.ExecContext(ctx, moveQuery, newPath, oldPath, newDepth-oldDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1 WHERE id = $2`, parentID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := scanCategory(tx.QueryRowContext(ctx, `SELECT `+selectColumns+` FROM categories c WHERE c.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return category, tx.Commit()
+}
+
+func (r *repository) GetSubtree(ctx context.Context, rootID *int64) ([]*Category, error) {
+	// Every category sorts after its parent, as the path of the parent is a prefix of its own
+	selectQuery := `SELECT ` + selectColumns + ` FROM categories c
+		WHERE $1::INT IS NULL OR c.path LIKE (SELECT path FROM categories WHERE id = $1) || '%'
+		ORDER BY c.depth, c.position, c.name, c.id`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *repository) GetBreadcrumbs(ctx context.Context, id int) ([]*Category, error) {
+	// The ancestors are the categories whose path is a prefix of the path of the category
+	selectQuery := `SELECT ` + selectColumns + ` FROM categories c
+		JOIN categories t ON t.path LIKE c.path || '%'
+		WHERE t.id = $1 ORDER BY c.depth`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanCategory reads a category selected with selectColumns
+func scanCategory(row scanner) (*Category, error) {
+	var category Category
+
+	err := row.Scan(
+		&category.ID,
+		&category.ParentID,
+		&category.Name,
+		&category.Slug,
+		&category.Description,
+		&category.Position,
+		&category.Path,
+		&category.Depth,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
@@ -0,0 +1,214 @@
+package category
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Service interface for the category service
+type Service interface {
+	// GetTree Retrieves the whole category tree, or the subtree of the root, with the children nested.
+	GetTree(c context.Context, rootID *int64) ([]*Category, error)
+
+	// GetCategory Retrieves a category by its ID.
+	GetCategory(c context.Context, id int) (*Category, error)
+
+	// GetBreadcrumbs Retrieves the ancestors of a category from the root down, the category included.
+	GetBreadcrumbs(c context.Context, id int) ([]*Category, error)
+
+	// CreateCategory Creates a new category and returns it.
+	CreateCategory(c context.Context, req *CreateCategoryReq) (*Category, error)
+
+	// UpdateCategory Updates a category and returns it.
+	UpdateCategory(c context.Context, req *UpdateCategoryReq) (*Category, error)
+
+	// MoveCategory Moves a category along with its subtree under another parent and returns it.
+	MoveCategory(c context.Context, req *MoveCategoryReq) (*Category, error)
+
+	// DeleteCategory Deletes a category without subcategories and returns a message indicating success or failure.
+	DeleteCategory(c context.Context, id int) (*utils.MessageRes, error)
+}
+
+// ErrCategoryNotFound is returned when the category doesn't exist.
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrParentNotFound is returned when the parent category doesn't exist.
+var ErrParentNotFound = errors.New("parent category not found")
+
+// ErrSlugTaken is returned when the slug belongs to another category.
+var ErrSlugTaken = errors.New("slug already in use")
+
+// ErrInvalidSlug is returned when the slug isn't made of lowercase letters and digits separated by single dashes.
+var ErrInvalidSlug = errors.New("slug must be lowercase letters and digits separated by single dashes")
+
+// ErrCategoryHasChildren is returned when deleting a category which still has subcategories.
+var ErrCategoryHasChildren = errors.New("category has subcategories, move or delete them first")
+
+// ErrInvalidMove is returned when moving a category under itself or one of its descendants.
+var ErrInvalidMove = errors.New("category can't be moved under itself or its descendants")
+
+type service struct {
+	categoryRepo Repository
+	timeout      time.Duration
+}
+
+// NewService initialize and return the Service
+func NewService(cr Repository) Service {
+	return &service{
+		categoryRepo: cr,
+		timeout:      time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) GetTree(c context.Context, rootID *int64) ([]*Category, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	categories, err := s.categoryRepo.GetSubtree(ctx, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if rootID != nil && len(categories) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	// The parents come first, so every child finds its parent already indexed
+	tree := []*Category{}
+	byID := make(map[int64]*Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+
+		var parent *Category
+		if category.ParentID != nil {
+			parent = byID[*category.ParentID]
+		}
+		// The root of a subtree has its parent left out
+		if parent != nil {
+			parent.Children = append(parent.Children, category)
+		} else {
+			tree = append(tree, category)
+		}
+	}
+
+	return tree, nil
+}
+
+func (s *service) GetCategory(c context.Context, id int) (*Category, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *service) GetBreadcrumbs(c context.Context, id int) ([]*Category, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	breadcrumbs, err := s.categoryRepo.GetBreadcrumbs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(breadcrumbs) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	return breadcrumbs, nil
+}
+
+func (s *service) CreateCategory(c context.Context, req *CreateCategoryReq) (*Category, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	slug := req.Slug
+	if slug == "" {
+		slug = utils.Slugify(req.Name, "category")
+	}
+	if !utils.IsSlug(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	category := &Category{
+		ParentID:    req.ParentID,
+		Name:        req.Name,
+		Slug:        slug,
+		Description: req.Description,
+		Position:    req.Position,
+	}
+
+	return s.categoryRepo.Create(ctx, category)
+}
+
+func (s *service) UpdateCategory(c context.Context, req *UpdateCategoryReq) (*Category, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if !utils.IsSlug(req.Slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	category := &Category{
+		ID:          req.ID,
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		Position:    req.Position,
+	}
+
+	updatedCategory, err := s.categoryRepo.Update(ctx, category)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedCategory, nil
+}
+
+func (s *service) MoveCategory(c context.Context, req *MoveCategoryReq) (*Category, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	category, err := s.categoryRepo.Move(ctx, int(req.ID), req.ParentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *service) DeleteCategory(c context.Context, id int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	err := s.categoryRepo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Category deleted.",
+	}
+
+	return res, nil
+}
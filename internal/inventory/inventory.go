@@ -0,0 +1,148 @@
+package inventory
+
+import "time"
+
+// Reservation statuses, only the active reservations hold stock
+const (
+	StatusActive    = "active"
+	StatusCommitted = "committed"
+	StatusReleased  = "released"
+	StatusExpired   = "expired"
+)
+
+// Stock movement kinds, recorded in the ledger for every change of the quantities
+const (
+	KindAdjustment  = "adjustment"
+	KindReservation = "reservation"
+	KindCommit      = "commit"
+	KindRelease     = "release"
+	KindExpiry      = "expiry"
+	KindTransfer    = "transfer"
+)
+
+// Allocation strategies, choosing the locations the order lines are shipped from
+const (
+	StrategyNearest        = "nearest"
+	StrategyHighestStock   = "highest_stock"
+	StrategySingleShipment = "single_shipment"
+)
+
+// Level represents the stock of a variant at a location. The reserved quantity is held for the checkouts in progress,
+// the available quantity is the rest of the on hand one.
+type Level struct {
+	VariantID  int64     `json:"variant_id"`
+	LocationID int64     `json:"location_id"`
+	OnHand     int       `json:"on_hand"`
+	Reserved   int       `json:"reserved"`
+	Available  int       `json:"available"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Stock represents the stock of a variant at every location, along with the totals.
+type Stock struct {
+	VariantID int64    `json:"variant_id"`
+	OnHand    int      `json:"on_hand"`
+	Reserved  int      `json:"reserved"`
+	Available int      `json:"available"`
+	Levels    []*Level `json:"levels"`
+}
+
+// Reservation represents stock of a variant held for a checkout until it's committed, released or expires.
+// The reservations of a checkout share its reference.
+type Reservation struct {
+	ID         int64     `json:"id"`
+	VariantID  int64     `json:"variant_id"`
+	LocationID int64     `json:"location_id"`
+	Reference  string    `json:"reference"`
+	Quantity   int       `json:"quantity"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Movement represents an entry of the stock ledger, which is never changed once written.
+// A transfer writes an entry at each end, with the other end as the related location.
+type Movement struct {
+	ID                int64     `json:"id"`
+	VariantID         int64     `json:"variant_id"`
+	LocationID        *int64    `json:"location_id"`
+	RelatedLocationID *int64    `json:"related_location_id"`
+	ReservationID     *int64    `json:"reservation_id"`
+	Kind              string    `json:"kind"`
+	OnHandChange      int       `json:"on_hand_change"`
+	ReservedChange    int       `json:"reserved_change"`
+	Reason            string    `json:"reason"`
+	ActorID           *int64    `json:"actor_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ReserveItem represents the quantity of a variant to reserve.
+type ReserveItem struct {
+	VariantID int64 `json:"variant_id" validate:"required"`
+	Quantity  int   `json:"quantity" validate:"min=1"`
+}
+
+// Coordinates represents a point on the globe, like the shipping address of an order.
+type Coordinates struct {
+	Latitude  float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"min=-180,max=180"`
+}
+
+// ReserveReq represents the request payload for reserving the stock of a checkout, all the items or none.
+// The strategy chooses the locations, the configured one without it, and the nearest ones are nearest to the destination.
+type ReserveReq struct {
+	Reference   string         `json:"reference" validate:"required,max=255"`
+	Items       []*ReserveItem `json:"items" validate:"required,min=1,max=100,dive,required"`
+	Strategy    string         `json:"strategy" validate:"omitempty,oneof=nearest highest_stock single_shipment"`
+	Destination *Coordinates   `json:"destination"`
+}
+
+// ReservationsRes represents the reservations of a checkout.
+type ReservationsRes struct {
+	Reference    string         `json:"reference"`
+	Reservations []*Reservation `json:"reservations"`
+}
+
+// AdjustStockReq represents the request payload for changing the on hand quantity of a variant of a vendor,
+// like a delivery or a stock count. The quantity is added, or removed when negative.
+type AdjustStockReq struct {
+	VariantID  int64  `json:"variant_id" validate:"required"`
+	VendorID   int64  `json:"vendor_id"`
+	LocationID int64  `json:"location_id" validate:"required"`
+	Quantity   int    `json:"quantity" validate:"required"`
+	Reason     string `json:"reason" validate:"required,max=255"`
+}
+
+// TransferStockReq represents the request payload for moving available stock of a variant of a vendor
+// between two of its locations.
+type TransferStockReq struct {
+	VariantID      int64  `json:"variant_id" validate:"required"`
+	VendorID       int64  `json:"vendor_id"`
+	FromLocationID int64  `json:"from_location_id" validate:"required"`
+	ToLocationID   int64  `json:"to_location_id" validate:"required,nefield=FromLocationID"`
+	Quantity       int    `json:"quantity" validate:"min=1"`
+	Reason         string `json:"reason" validate:"max=255"`
+}
+
+// TransferStockRes represents the stock at both ends of a transfer.
+type TransferStockRes struct {
+	From *Level `json:"from"`
+	To   *Level `json:"to"`
+}
+
+// ListMovementsReq represents the pagination of the stock ledger of a variant of a vendor, the latest entries first.
+type ListMovementsReq struct {
+	VariantID int64 `json:"variant_id" validate:"required"`
+	VendorID  int64 `json:"vendor_id"`
+	Page      int   `json:"page" validate:"min=1"`
+	PageSize  int   `json:"page_size" validate:"min=1,max=100"`
+}
+
+// ListMovementsRes represents a page of the stock ledger of a variant.
+type ListMovementsRes struct {
+	Movements []*Movement `json:"movements"`
+	Page      int         `json:"page"`
+	PageSize  int         `json:"page_size"`
+	Total     int         `json:"total"`
+}
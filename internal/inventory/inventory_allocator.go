@@ -0,0 +1,109 @@
+package inventory
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Candidate represents the available stock of a variant at an active location, which an order line can be shipped from.
+type Candidate struct {
+	VariantID  int64
+	LocationID int64
+	Available  int
+	Priority   int
+	Latitude   *float64
+	Longitude  *float64
+}
+
+// Allocation represents the quantity of a variant shipped from a location.
+type Allocation struct {
+	VariantID  int64
+	LocationID int64
+	Quantity   int
+}
+
+// Allocator interface for choosing the locations the order lines are shipped from
+type Allocator interface {
+	// Allocate splits the quantities of the items among the candidates, returns ErrInsufficientStock
+	// if an item can't be covered. The destination is optional.
+	Allocate(items []*ReserveItem, candidates []*Candidate, destination *Coordinates) ([]*Allocation, error)
+}
+
+// NewAllocator initialize and return the Allocator of the strategy, an unknown strategy is rejected
+// so a misconfigured server fails on startup.
+func NewAllocator(strategy string) (Allocator, error) {
+	switch strategy {
+	case StrategyNearest:
+		return NewNearestAllocator(), nil
+	case StrategyHighestStock:
+		return NewHighestStockAllocator(), nil
+	case StrategySingleShipment:
+		return NewSingleShipmentAllocator(), nil
+	default:
+		return nil, fmt.Errorf("unsupported allocation strategy %q", strategy)
+	}
+}
+
+// allocateInOrder covers every item from its candidates in the order chosen by compare, taking as much as
+// each location has before moving to the next one
+func allocateInOrder(items []*ReserveItem, candidates []*Candidate, compare func(a, b *Candidate) int) ([]*Allocation, error) {
+	allocations := []*Allocation{}
+	for _, item := range items {
+		var ordered []*Candidate
+		for _, candidate := range candidates {
+			if candidate.VariantID == item.VariantID && candidate.Available > 0 {
+				ordered = append(ordered, candidate)
+			}
+		}
+		slices.SortStableFunc(ordered, compare)
+
+		remaining := item.Quantity
+		for _, candidate := range ordered {
+			if remaining == 0 {
+				break
+			}
+
+			quantity := min(remaining, candidate.Available)
+			allocations = append(allocations, &Allocation{
+				VariantID:  item.VariantID,
+				LocationID: candidate.LocationID,
+				Quantity:   quantity,
+			})
+			remaining -= quantity
+		}
+
+		if remaining > 0 {
+			return nil, fmt.Errorf("%w: variant %d", ErrInsufficientStock, item.VariantID)
+		}
+	}
+
+	return allocations, nil
+}
+
+// byPriority prefers the locations of higher priority, then the older ones
+func byPriority(a, b *Candidate) int {
+	if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.LocationID, b.LocationID)
+}
+
+// distance returns the great-circle distance in kilometres between the candidate and the destination,
+// infinite when either is unknown so those locations come last
+func distance(candidate *Candidate, destination *Coordinates) float64 {
+	if destination == nil || candidate.Latitude == nil || candidate.Longitude == nil {
+		return math.Inf(1)
+	}
+
+	const earthRadius = 6371.0
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	lat1, lat2 := toRadians(*candidate.Latitude), toRadians(destination.Latitude)
+	dLat := lat2 - lat1
+	dLon := toRadians(destination.Longitude - *candidate.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,20 @@
+package inventory
+
+import "cmp"
+
+type highestStockAllocator struct{}
+
+// NewHighestStockAllocator returns an Allocator shipping every order line from the locations with the most
+// available stock of its variant, which spreads the stock out the least.
+func NewHighestStockAllocator() Allocator {
+	return &highestStockAllocator{}
+}
+
+func (a *highestStockAllocator) Allocate(items []*ReserveItem, candidates []*Candidate, destination *Coordinates) ([]*Allocation, error) {
+	return allocateInOrder(items, candidates, func(x, y *Candidate) int {
+		if c := cmp.Compare(y.Available, x.Available); c != 0 {
+			return c
+		}
+		return byPriority(x, y)
+	})
+}
@@ -0,0 +1,20 @@
+package inventory
+
+import "cmp"
+
+type nearestAllocator struct{}
+
+// NewNearestAllocator returns an Allocator shipping every order line from the locations nearest to the destination,
+// the ones without coordinates last. Without a destination the locations of higher priority are used first.
+func NewNearestAllocator() Allocator {
+	return &nearestAllocator{}
+}
+
+func (a *nearestAllocator) Allocate(items []*ReserveItem, candidates []*Candidate, destination *Coordinates) ([]*Allocation, error) {
+	return allocateInOrder(items, candidates, func(x, y *Candidate) int {
+		if c := cmp.Compare(distance(x, destination), distance(y, destination)); c != 0 {
+			return c
+		}
+		return byPriority(x, y)
+	})
+}
@@ -0,0 +1,92 @@
+package inventory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+type singleShipmentAllocator struct{}
+
+// NewSingleShipmentAllocator returns an Allocator shipping the whole order from a single location when one has
+// every item, the nearest of them or the one of higher priority. Otherwise the location covering the most of what's
+// left is used first, so the order ships in as few parcels as possible.
+func NewSingleShipmentAllocator() Allocator {
+	return &singleShipmentAllocator{}
+}
+
+func (a *singleShipmentAllocator) Allocate(items []*ReserveItem, candidates []*Candidate, destination *Coordinates) ([]*Allocation, error) {
+	type stockKey struct{ variantID, locationID int64 }
+
+	available := make(map[stockKey]int)
+	locations := make(map[int64]*Candidate)
+	for _, candidate := range candidates {
+		available[stockKey{candidate.VariantID, candidate.LocationID}] = candidate.Available
+		if _, ok := locations[candidate.LocationID]; !ok {
+			locations[candidate.LocationID] = candidate
+		}
+	}
+
+	// The locations are compared by the candidates they were first seen with, all of them share the coordinates
+	// and the priority of their location
+	ordered := make([]*Candidate, 0, len(locations))
+	for _, location := range locations {
+		ordered = append(ordered, location)
+	}
+	slices.SortFunc(ordered, func(x, y *Candidate) int {
+		if c := cmp.Compare(distance(x, destination), distance(y, destination)); c != 0 {
+			return c
+		}
+		return byPriority(x, y)
+	})
+
+	remaining := make(map[int64]int, len(items))
+	for _, item := range items {
+		remaining[item.VariantID] = item.Quantity
+	}
+
+	allocations := []*Allocation{}
+	for {
+		// The location covering the most of what's left is used next, the first in order on a tie,
+		// so a location having every item is always used alone
+		var best *Candidate
+		bestCovered := 0
+		for _, location := range ordered {
+			covered := 0
+			for _, item := range items {
+				covered += min(remaining[item.VariantID], available[stockKey{item.VariantID, location.LocationID}])
+			}
+			if covered > bestCovered {
+				best, bestCovered = location, covered
+			}
+		}
+
+		if best == nil {
+			break
+		}
+
+		for _, item := range items {
+			key := stockKey{item.VariantID, best.LocationID}
+			quantity := min(remaining[item.VariantID], available[key])
+			if quantity == 0 {
+				continue
+			}
+
+			allocations = append(allocations, &Allocation{
+				VariantID:  item.VariantID,
+				LocationID: best.LocationID,
+				Quantity:   quantity,
+			})
+			remaining[item.VariantID] -= quantity
+			available[key] -= quantity
+		}
+	}
+
+	for _, item := range items {
+		if remaining[item.VariantID] > 0 {
+			return nil, fmt.Errorf("%w: variant %d", ErrInsufficientStock, item.VariantID)
+		}
+	}
+
+	return allocations, nil
+}
@@ -0,0 +1,272 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func coordinate(value float64) *float64 {
+	return &value
+}
+
+// candidate returns the stock of the variant at a location, the locations without coordinates are given nil ones
+func candidate(variantID int64, locationID int64, available int, priority int, coordinates *Coordinates) *Candidate {
+	c := &Candidate{
+		VariantID:  variantID,
+		LocationID: locationID,
+		Available:  available,
+		Priority:   priority,
+	}
+	if coordinates != nil {
+		c.Latitude = coordinate(coordinates.Latitude)
+		c.Longitude = coordinate(coordinates.Longitude)
+	}
+	return c
+}
+
+func item(variantID int64, quantity int) *ReserveItem {
+	return &ReserveItem{VariantID: variantID, Quantity: quantity}
+}
+
+func allocation(variantID int64, locationID int64, quantity int) *Allocation {
+	return &Allocation{VariantID: variantID, LocationID: locationID, Quantity: quantity}
+}
+
+var (
+	// The destination is next to the near location
+	destination = &Coordinates{Latitude: 1, Longitude: 1}
+	near        = &Coordinates{Latitude: 0, Longitude: 0}
+	far         = &Coordinates{Latitude: 10, Longitude: 10}
+)
+
+func TestAllocators(t *testing.T) {
+	tests := []struct {
+		name        string
+		allocator   Allocator
+		items       []*ReserveItem
+		candidates  []*Candidate
+		destination *Coordinates
+		want        []*Allocation
+		wantErr     error
+	}{
+		{
+			name:        "nearest ships from the nearest location",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 2)},
+			candidates:  []*Candidate{candidate(1, 1, 5, 0, far), candidate(1, 2, 5, 0, near)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 2)},
+		},
+		{
+			name:        "nearest splits the line when the nearest location is short",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 3)},
+			candidates:  []*Candidate{candidate(1, 1, 5, 0, far), candidate(1, 2, 1, 0, near)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 1), allocation(1, 1, 2)},
+		},
+		{
+			name:        "nearest uses the locations without coordinates last",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 4)},
+			candidates:  []*Candidate{candidate(1, 1, 3, 10, nil), candidate(1, 2, 3, 0, far)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 3), allocation(1, 1, 1)},
+		},
+		{
+			name:       "nearest falls back to the priority without a destination",
+			allocator:  NewNearestAllocator(),
+			items:      []*ReserveItem{item(1, 2)},
+			candidates: []*Candidate{candidate(1, 1, 5, 0, near), candidate(1, 2, 5, 5, far), candidate(1, 3, 5, 1, nil)},
+			want:       []*Allocation{allocation(1, 2, 2)},
+		},
+		{
+			name:        "nearest breaks a distance tie by the priority",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 2)},
+			candidates:  []*Candidate{candidate(1, 1, 5, 0, near), candidate(1, 2, 5, 1, near)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 2)},
+		},
+		{
+			name:        "nearest breaks a full tie by the older location",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 2)},
+			candidates:  []*Candidate{candidate(1, 2, 5, 0, near), candidate(1, 1, 5, 0, near)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 1, 2)},
+		},
+		{
+			name:        "nearest skips the locations without available stock",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 2)},
+			candidates:  []*Candidate{candidate(1, 1, 0, 0, near), candidate(1, 2, 5, 0, far)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 2)},
+		},
+		{
+			name:        "nearest fails when the stock is insufficient",
+			allocator:   NewNearestAllocator(),
+			items:       []*ReserveItem{item(1, 5)},
+			candidates:  []*Candidate{candidate(1, 1, 2, 0, near), candidate(1, 2, 2, 0, far)},
+			destination: destination,
+			wantErr:     ErrInsufficientStock,
+		},
+		{
+			name:        "highest stock ships from the location with the most stock",
+			allocator:   NewHighestStockAllocator(),
+			items:       []*ReserveItem{item(1, 5)},
+			candidates:  []*Candidate{candidate(1, 1, 3, 5, near), candidate(1, 2, 7, 0, far)},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 5)},
+		},
+		{
+			name:       "highest stock splits the line when the location is short",
+			allocator:  NewHighestStockAllocator(),
+			items:      []*ReserveItem{item(1, 9)},
+			candidates: []*Candidate{candidate(1, 1, 3, 0, nil), candidate(1, 2, 7, 0, nil)},
+			want:       []*Allocation{allocation(1, 2, 7), allocation(1, 1, 2)},
+		},
+		{
+			name:       "highest stock breaks a tie by the priority",
+			allocator:  NewHighestStockAllocator(),
+			items:      []*ReserveItem{item(1, 2)},
+			candidates: []*Candidate{candidate(1, 1, 5, 0, nil), candidate(1, 2, 5, 3, nil)},
+			want:       []*Allocation{allocation(1, 2, 2)},
+		},
+		{
+			name:       "highest stock fails when a variant has no stock",
+			allocator:  NewHighestStockAllocator(),
+			items:      []*ReserveItem{item(1, 1), item(2, 1)},
+			candidates: []*Candidate{candidate(1, 1, 5, 0, nil)},
+			wantErr:    ErrInsufficientStock,
+		},
+		{
+			name:      "single shipment prefers a location having every item",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 1), item(2, 1)},
+			candidates: []*Candidate{
+				candidate(1, 1, 5, 0, near),
+				candidate(1, 2, 5, 0, far), candidate(2, 2, 5, 0, far),
+			},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 1), allocation(2, 2, 1)},
+		},
+		{
+			name:      "single shipment picks the nearest of the locations having every item",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 1), item(2, 1)},
+			candidates: []*Candidate{
+				candidate(1, 1, 5, 5, far), candidate(2, 1, 5, 5, far),
+				candidate(1, 2, 5, 0, near), candidate(2, 2, 5, 0, near),
+			},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 1), allocation(2, 2, 1)},
+		},
+		{
+			name:      "single shipment uses the locations without coordinates last",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 1), item(2, 1)},
+			candidates: []*Candidate{
+				candidate(1, 1, 5, 10, nil), candidate(2, 1, 5, 10, nil),
+				candidate(1, 2, 5, 0, far), candidate(2, 2, 5, 0, far),
+			},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 2, 1), allocation(2, 2, 1)},
+		},
+		{
+			name:      "single shipment breaks a tie by the priority without a destination",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 1), item(2, 1)},
+			candidates: []*Candidate{
+				candidate(1, 1, 5, 0, near), candidate(2, 1, 5, 0, near),
+				candidate(1, 2, 5, 1, far), candidate(2, 2, 5, 1, far),
+			},
+			want: []*Allocation{allocation(1, 2, 1), allocation(2, 2, 1)},
+		},
+		{
+			name:      "single shipment splits the order when no location has every item",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 2), item(2, 2)},
+			candidates: []*Candidate{
+				candidate(1, 1, 5, 0, near), candidate(2, 1, 0, 0, near),
+				candidate(2, 2, 5, 0, far),
+			},
+			destination: destination,
+			want:        []*Allocation{allocation(1, 1, 2), allocation(2, 2, 2)},
+		},
+		{
+			name:      "single shipment uses the location covering the most first",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 3), item(2, 3)},
+			candidates: []*Candidate{
+				candidate(1, 1, 1, 0, near), candidate(2, 1, 1, 0, near),
+				candidate(1, 2, 2, 0, far), candidate(2, 2, 3, 0, far),
+			},
+			destination: destination,
+			want: []*Allocation{
+				allocation(1, 2, 2), allocation(2, 2, 3),
+				allocation(1, 1, 1),
+			},
+		},
+		{
+			name:      "single shipment fails when the stock is insufficient",
+			allocator: NewSingleShipmentAllocator(),
+			items:     []*ReserveItem{item(1, 2), item(2, 2)},
+			candidates: []*Candidate{
+				candidate(1, 1, 2, 0, near), candidate(2, 1, 1, 0, near),
+			},
+			destination: destination,
+			wantErr:     ErrInsufficientStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.allocator.Allocate(tt.items, tt.candidates, tt.destination)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %s, want %s", formatAllocations(got), formatAllocations(tt.want))
+			}
+		})
+	}
+}
+
+func TestNewAllocator(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     Allocator
+		wantErr  bool
+	}{
+		{StrategyNearest, NewNearestAllocator(), false},
+		{StrategyHighestStock, NewHighestStockAllocator(), false},
+		{StrategySingleShipment, NewSingleShipmentAllocator(), false},
+		{"", nil, true},
+		{"unknown", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			got, err := NewAllocator(tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewAllocator(%q) error = %v, wantErr %v", tt.strategy, err, tt.wantErr)
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Errorf("NewAllocator(%q) = %T, want %T", tt.strategy, got, tt.want)
+			}
+		})
+	}
+}
+
+// formatAllocations lists the allocations as variant@location:quantity
+func formatAllocations(allocations []*Allocation) string {
+	formatted := make([]string, len(allocations))
+	for i, a := range allocations {
+		formatted[i] = fmt.Sprintf("%d@%d:%d", a.VariantID, a.LocationID, a.Quantity)
+	}
+	return fmt.Sprint(formatted)
+}
@@ -0,0 +1,322 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler struct to hold the inventory service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the inventory Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// GetStock      godoc
+// @Summary      Get Variant Stock
+// @Description  Get the on hand, reserved and available stock of a variant of the vendor at each of its locations, along with the totals
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        variant_id  path  int  true  "Variant ID"
+// @Success      200  {object}  Stock
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/inventory/{variant_id} [get]
+func (h *Handler) GetStock(w http.ResponseWriter, r *http.Request) {
+	vendorID, variantID, err := readVendorVariantIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetStock(r.Context(), vendorID, variantID)
+	if errors.Is(err, ErrVariantNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// AdjustStock   godoc
+// @Summary      Adjust Variant Stock
+// @Description  Add to the on hand stock of a variant of the vendor at a location, or remove from it with a negative quantity, recording the reason in the ledger
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        variant_id  path  int  true  "Variant ID"
+// @Param        body  body  AdjustStockReq  true  "Stock adjustment request"
+// @Success      200  {object}  Level
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/inventory/{variant_id}/adjust [post]
+func (h *Handler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	vendorID, variantID, err := readVendorVariantIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var adjustStockReq AdjustStockReq
+	if err := utils.ReadFromRequest(r, &adjustStockReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	adjustStockReq.VariantID = int64(variantID)
+	adjustStockReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(adjustStockReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.AdjustStock(r.Context(), &adjustStockReq)
+	if errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrLocationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrInsufficientStock) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// TransferStock godoc
+// @Summary      Transfer Variant Stock
+// @Description  Move available stock of a variant of the vendor between two of its locations, recording both ends in the ledger
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        variant_id  path  int  true  "Variant ID"
+// @Param        body  body  TransferStockReq  true  "Stock transfer request"
+// @Success      200  {object}  TransferStockRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/inventory/{variant_id}/transfer [post]
+func (h *Handler) TransferStock(w http.ResponseWriter, r *http.Request) {
+	vendorID, variantID, err := readVendorVariantIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var transferStockReq TransferStockReq
+	if err := utils.ReadFromRequest(r, &transferStockReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	transferStockReq.VariantID = int64(variantID)
+	transferStockReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(transferStockReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.TransferStock(r.Context(), &transferStockReq)
+	if errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrLocationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrInsufficientStock) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ListMovements godoc
+// @Summary      List Stock Movements
+// @Description  List the stock ledger of a variant of the vendor page by page, the latest entries first
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path   int  true   "Vendor user ID"
+// @Param        variant_id  path   int  true   "Variant ID"
+// @Param        page        query  int  false  "Page number, starting at 1"  default(1)
+// @Param        page_size   query  int  false  "Entries per page, at most 100"  default(20)
+// @Success      200  {object}  ListMovementsRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/inventory/{variant_id}/movements [get]
+func (h *Handler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	vendorID, variantID, err := readVendorVariantIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	listMovementsReq := ListMovementsReq{
+		VariantID: int64(variantID),
+		VendorID:  int64(vendorID),
+		Page:      1,
+		PageSize:  20,
+	}
+	if page := r.URL.Query().Get("page"); page != "" {
+		if listMovementsReq.Page, err = strconv.Atoi(page); err != nil {
+			utils.WriterErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid page: %v", err))
+			return
+		}
+	}
+	if pageSize := r.URL.Query().Get("page_size"); pageSize != "" {
+		if listMovementsReq.PageSize, err = strconv.Atoi(pageSize); err != nil {
+			utils.WriterErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid page_size: %v", err))
+			return
+		}
+	}
+
+	if err := utils.Validate.Struct(listMovementsReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ListMovements(r.Context(), &listMovementsReq)
+	if errors.Is(err, ErrVariantNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// Reserve       godoc
+// @Summary      Reserve Stock
+// @Description  Hold the stock of the items of a checkout until it's committed, released or expires, all the items or none, at the locations chosen by the allocation strategy
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ReserveReq  true  "Stock reservation request"
+// @Success      201  {object}  ReservationsRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /inventory/reservations [post]
+func (h *Handler) Reserve(w http.ResponseWriter, r *http.Request) {
+	var reserveReq ReserveReq
+	if err := utils.ReadFromRequest(r, &reserveReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(reserveReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.Reserve(r.Context(), &reserveReq)
+	if errors.Is(err, ErrInsufficientStock) || errors.Is(err, ErrReferenceInUse) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// CommitReservations godoc
+// @Summary      Commit Reservations
+// @Description  Take the stock held for a checkout off the on hand stock once the order is placed
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        reference  path  string  true  "Checkout reference"
+// @Success      200  {object}  ReservationsRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      410  {object}  utils.MessageRes
+// @Router       /inventory/reservations/{reference}/commit [post]
+func (h *Handler) CommitReservations(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.CommitReservations(r.Context(), chi.URLParam(r, "reference"))
+	if errors.Is(err, ErrReservationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrReservationExpired) {
+		utils.WriterErrorResponse(w, http.StatusGone, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ReleaseReservations godoc
+// @Summary      Release Reservations
+// @Description  Give the stock held for a checkout back, when it's abandoned or the payment fails
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        reference  path  string  true  "Checkout reference"
+// @Success      200  {object}  ReservationsRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /inventory/reservations/{reference}/release [post]
+func (h *Handler) ReleaseReservations(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.ReleaseReservations(r.Context(), chi.URLParam(r, "reference"))
+	if errors.Is(err, ErrReservationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// readVendorVariantIDs reads the vendor and variant ids from the url.
+func readVendorVariantIDs(r *http.Request) (int, int, error) {
+	vendorID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	variantID, err := strconv.Atoi(chi.URLParam(r, "variant_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return vendorID, variantID, nil
+}
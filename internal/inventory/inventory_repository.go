@@ -0,0 +1,597 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/lib/pq"
+)
+
+// Repository interface for the inventory repository
+type Repository interface {
+	// GetLevels returns the stock of the variant at every location it has stock records at
+	GetLevels(ctx context.Context, variantID int) ([]*Level, error)
+
+	// Adjust adds the quantity to the on hand stock of the variant at the location, or removes it when negative, and
+	// records it in the ledger. Returns ErrInsufficientStock if the on hand stock would fall below the reserved one.
+	Adjust(ctx context.Context, variantID int, locationID int, quantity int, reason string, actorID int64) (*Level, error)
+
+	// Transfer moves available stock of the variant between the locations and records both ends in the ledger.
+	// Returns ErrInsufficientStock if the source doesn't have the quantity available.
+	Transfer(ctx context.Context, variantID int, fromLocationID int, toLocationID int, quantity int, reason string, actorID int64) (*Level, *Level, error)
+
+	// Reserve holds the quantities of the variants until the expiry in a single transaction, all of them or none.
+	// The stock of the variants at the active locations is locked and handed to allocate, which chooses where
+	// each quantity is held. Returns ErrInsufficientStock if a variant doesn't have the quantity available and
+	// ErrReferenceInUse if the reference already holds or sold stock.
+	Reserve(ctx context.Context, reference string, variantIDs []int64, expiresAt time.Time,
+		allocate func(candidates []*Candidate) ([]*Allocation, error)) ([]*Reservation, error)
+
+	// Commit takes the stock held by the active reservations of the reference off the on hand stock, as it's sold.
+	// Returns sql.ErrNoRows if the reference has no active reservations and ErrReservationExpired if one of them expired.
+	Commit(ctx context.Context, reference string) ([]*Reservation, error)
+
+	// Release gives the stock held by the active reservations of the reference back to the available stock.
+	// Returns sql.ErrNoRows if the reference has no active reservations.
+	Release(ctx context.Context, reference string) ([]*Reservation, error)
+
+	// Clear writes off the on hand stock of the variant at every location in the ledger and removes its stock records
+	// and finished reservations, so the variant can be deleted. Returns ErrStockReserved if checkouts hold its stock.
+	Clear(ctx context.Context, variantID int, reason string, actorID int64) error
+
+	// ReleaseExpired releases at most limit of the expired reservations and returns how many were released.
+	ReleaseExpired(ctx context.Context, limit int) (int, error)
+
+	// ListMovements returns a page of the ledger of the variant, the latest entries first, along with the total count of entries
+	ListMovements(ctx context.Context, variantID int, limit int, offset int) ([]*Movement, int, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+// insertMovementQuery writes an entry of the stock ledger
+const insertMovementQuery = `INSERT INTO stock_movements(variant_id, location_id, related_location_id, reservation_id,
+	kind, on_hand_change, reserved_change, reason, actor_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+func (r *repository) GetLevels(ctx context.Context, variantID int) ([]*Level, error) {
+	selectQuery := `SELECT variant_id, location_id, on_hand, reserved, updated_at FROM inventory_levels
+		WHERE variant_id = $1 ORDER BY location_id`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, variantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []*Level{}
+	for rows.Next() {
+		level, err := scanLevel(rows)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, rows.Err()
+}
+
+func (r *repository) Adjust(ctx context.Context, variantID int, locationID int, quantity int, reason string, actorID int64) (*Level, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	level, err := adjustLevel(ctx, tx, variantID, locationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, insertMovementQuery, variantID, locationID, nil, nil, KindAdjustment, quantity, 0, reason, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return level, tx.Commit()
+}
+
+func (r *repository) Transfer(ctx context.Context, variantID int, fromLocationID int, toLocationID int, quantity int, reason string, actorID int64) (*Level, *Level, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// Both ends are locked in the order of their ids, so opposite transfers don't deadlock
+	lockQuery := `SELECT location_id FROM inventory_levels WHERE variant_id = $1 AND location_id IN ($2, $3)
+		ORDER BY location_id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, lockQuery, variantID, fromLocationID, toLocationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	// Only the available stock moves, the reserved one stays for its checkouts
+	takeQuery := `UPDATE inventory_levels SET on_hand = on_hand - $3, updated_at = CURRENT_TIMESTAMP
+		WHERE variant_id = $1 AND location_id = $2 AND on_hand - reserved >= $3
+		RETURNING variant_id, location_id, on_hand, reserved, updated_at`
+
+	from, err := scanLevel(tx.QueryRowContext(ctx, takeQuery, variantID, fromLocationID, quantity))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("%w: location %d", ErrInsufficientStock, fromLocationID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	to, err := adjustLevel(ctx, tx, variantID, toLocationID, quantity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, insertMovementQuery, variantID, fromLocationID, toLocationID, nil, KindTransfer, -quantity, 0, reason, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, insertMovementQuery, variantID, toLocationID, fromLocationID, nil, KindTransfer, quantity, 0, reason, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return from, to, tx.Commit()
+}
+
+func (r *repository) Reserve(ctx context.Context, reference string, variantIDs []int64, expiresAt time.Time,
+	allocate func(candidates []*Candidate) ([]*Allocation, error)) ([]*Reservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A retried checkout doesn't hold its stock twice, the reference is locked so its concurrent retries wait
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('stock_reservations:' || $1))`, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var inUse bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM stock_reservations WHERE reference = $1 AND status IN ($2, $3))`,
+		reference, StatusActive, StatusCommitted).Scan(&inUse)
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		return nil, ErrReferenceInUse
+	}
+
+	// The stock is locked in the order of the variants and the locations, so concurrent checkouts
+	// allocate from what's really left and don't deadlock
+	selectQuery := `SELECT l.variant_id, l.location_id, l.on_hand - l.reserved, loc.priority, loc.latitude, loc.longitude
+		FROM inventory_levels l JOIN locations loc ON loc.id = l.location_id
+		WHERE l.variant_id = ANY($1) AND loc.active
+		ORDER BY l.variant_id, l.location_id FOR UPDATE OF l`
+
+	rows, err := tx.QueryContext(ctx, selectQuery, pq.Array(variantIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*Candidate{}
+	for rows.Next() {
+		var candidate Candidate
+		err := rows.Scan(
+			&candidate.VariantID,
+			&candidate.LocationID,
+			&candidate.Available,
+			&candidate.Priority,
+			&candidate.Latitude,
+			&candidate.Longitude,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, &candidate)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	allocations, err := allocate(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// The available stock is checked again, in case the allocation took more than a location has
+	reserveQuery := `UPDATE inventory_levels SET reserved = reserved + $3, updated_at = CURRENT_TIMESTAMP
+		WHERE variant_id = $1 AND location_id = $2 AND on_hand - reserved >= $3`
+	insertQuery := `INSERT INTO stock_reservations(variant_id, location_id, reference, quantity, expires_at) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at, updated_at`
+
+	reservations := []*Reservation{}
+	for _, allocation := range allocations {
+		result, err := tx.ExecContext(ctx, reserveQuery, allocation.VariantID, allocation.LocationID, allocation.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if rowsAffected == 0 {
+			return nil, fmt.Errorf("%w: variant %d", ErrInsufficientStock, allocation.VariantID)
+		}
+
+		reservation := &Reservation{
+			VariantID:  allocation.VariantID,
+			LocationID: allocation.LocationID,
+			Reference:  reference,
+			Quantity:   allocation.Quantity,
+			ExpiresAt:  expiresAt,
+		}
+		err = tx.QueryRowContext(ctx, insertQuery,
+			allocation.VariantID,
+			allocation.LocationID,
+			reference,
+			allocation.Quantity,
+			expiresAt,
+		).Scan(
+			&reservation.ID,
+			&reservation.Status,
+			&reservation.CreatedAt,
+			&reservation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, insertMovementQuery,
+			allocation.VariantID,
+			allocation.LocationID,
+			nil,
+			reservation.ID,
+			KindReservation,
+			0,
+			allocation.Quantity,
+			reference,
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, tx.Commit()
+}
+
+func (r *repository) Commit(ctx context.Context, reference string) ([]*Reservation, error) {
+	return r.finish(ctx, reference, StatusCommitted, KindCommit)
+}
+
+func (r *repository) Release(ctx context.Context, reference string) ([]*Reservation, error) {
+	return r.finish(ctx, reference, StatusReleased, KindRelease)
+}
+
+// finish ends the active reservations of the reference in a single transaction, committing or releasing their stock
+func (r *repository) finish(ctx context.Context, reference string, status string, kind string) ([]*Reservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The reservations are locked, so they can't be committed, released or expire twice
+	selectQuery := `SELECT id, variant_id, location_id, reference, quantity, status, expires_at, created_at, updated_at,
+		expires_at <= CURRENT_TIMESTAMP
+		FROM stock_reservations WHERE reference = $1 AND status = 'active'
+		ORDER BY variant_id, location_id, id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, selectQuery, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := []*Reservation{}
+	expired := false
+	for rows.Next() {
+		var reservation Reservation
+		var isExpired bool
+		err := rows.Scan(
+			&reservation.ID,
+			&reservation.VariantID,
+			&reservation.LocationID,
+			&reservation.Reference,
+			&reservation.Quantity,
+			&reservation.Status,
+			&reservation.ExpiresAt,
+			&reservation.CreatedAt,
+			&reservation.UpdatedAt,
+			&isExpired,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expired = expired || isExpired
+		reservations = append(reservations, &reservation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(reservations) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	// An expired checkout is never sold, the sweeper gives its stock back
+	if expired && status == StatusCommitted {
+		return nil, ErrReservationExpired
+	}
+
+	if err := finishReservations(ctx, tx, reservations, status, kind); err != nil {
+		return nil, err
+	}
+
+	return reservations, tx.Commit()
+}
+
+func (r *repository) Clear(ctx context.Context, variantID int, reason string, actorID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	selectQuery := `SELECT variant_id, location_id, on_hand, reserved, updated_at FROM inventory_levels
+		WHERE variant_id = $1 ORDER BY location_id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, selectQuery, variantID)
+	if err != nil {
+		return err
+	}
+
+	levels := []*Level{}
+	for rows.Next() {
+		level, err := scanLevel(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		levels = append(levels, level)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// The stock written off is recorded in the ledger like any other adjustment
+	for _, level := range levels {
+		if level.Reserved > 0 {
+			return ErrStockReserved
+		}
+		if level.OnHand == 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, insertMovementQuery, variantID, level.LocationID, nil, nil, KindAdjustment, -level.OnHand, 0, reason, actorID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM inventory_levels WHERE variant_id = $1`, variantID)
+	if err != nil {
+		return err
+	}
+
+	// The active reservations hold reserved stock, so only the finished ones are left
+	_, err = tx.ExecContext(ctx, `DELETE FROM stock_reservations WHERE variant_id = $1`, variantID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) ReleaseExpired(ctx context.Context, limit int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// The reservations being committed or released right now are skipped, they are done with anyway
+	selectQuery := `SELECT id, variant_id, location_id, reference, quantity FROM stock_reservations
+		WHERE status = 'active' AND expires_at <= CURRENT_TIMESTAMP
+		ORDER BY variant_id, location_id, id LIMIT $1 FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, selectQuery, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	reservations := []*Reservation{}
+	for rows.Next() {
+		var reservation Reservation
+		err := rows.Scan(
+			&reservation.ID,
+			&reservation.VariantID,
+			&reservation.LocationID,
+			&reservation.Reference,
+			&reservation.Quantity,
+		)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		reservations = append(reservations, &reservation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(reservations) == 0 {
+		return 0, nil
+	}
+
+	if err := finishReservations(ctx, tx, reservations, StatusExpired, KindExpiry); err != nil {
+		return 0, err
+	}
+
+	return len(reservations), tx.Commit()
+}
+
+func (r *repository) ListMovements(ctx context.Context, variantID int, limit int, offset int) ([]*Movement, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM stock_movements WHERE variant_id = $1`
+
+	err := r.db.QueryRowContext(ctx, countQuery, variantID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	selectQuery := `SELECT id, variant_id, location_id, related_location_id, reservation_id, kind, on_hand_change,
+		reserved_change, reason, actor_id, created_at
+		FROM stock_movements WHERE variant_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, variantID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	movements := []*Movement{}
+	for rows.Next() {
+		var movement Movement
+		err := rows.Scan(
+			&movement.ID,
+			&movement.VariantID,
+			&movement.LocationID,
+			&movement.RelatedLocationID,
+			&movement.ReservationID,
+			&movement.Kind,
+			&movement.OnHandChange,
+			&movement.ReservedChange,
+			&movement.Reason,
+			&movement.ActorID,
+			&movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, &movement)
+	}
+
+	return movements, total, rows.Err()
+}
+
+// adjustLevel adds the quantity to the on hand stock of the variant at the location, creating its stock record
+// on the first change. The check constraint keeps the on hand stock from falling below the reserved one.
+func adjustLevel(ctx context.Context, tx *sql.Tx, variantID int, locationID int, quantity int) (*Level, error) {
+	adjustQuery := `INSERT INTO inventory_levels(variant_id, location_id, on_hand) VALUES ($1, $2, $3)
+		ON CONFLICT (variant_id, location_id) DO UPDATE SET on_hand = inventory_levels.on_hand + $3, updated_at = CURRENT_TIMESTAMP
+		RETURNING variant_id, location_id, on_hand, reserved, updated_at`
+
+	level, err := scanLevel(tx.QueryRowContext(ctx, adjustQuery, variantID, locationID, quantity))
+	if utils.IsCheckViolation(err) {
+		return nil, ErrInsufficientStock
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return level, nil
+}
+
+// finishReservations sets the status of the locked reservations, takes their quantities off the reserved stock,
+// and off the on hand stock too when committed, and records the changes in the ledger
+func finishReservations(ctx context.Context, tx *sql.Tx, reservations []*Reservation, status string, kind string) error {
+	ids := make([]int64, len(reservations))
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+	}
+
+	statusQuery := `UPDATE stock_reservations SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = ANY($2)`
+
+	_, err := tx.ExecContext(ctx, statusQuery, status, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	levelQuery := `UPDATE inventory_levels SET on_hand = on_hand - $3, reserved = reserved - $4, updated_at = CURRENT_TIMESTAMP
+		WHERE variant_id = $1 AND location_id = $2`
+
+	for _, reservation := range reservations {
+		onHandChange := 0
+		if status == StatusCommitted {
+			onHandChange = -reservation.Quantity
+		}
+
+		_, err = tx.ExecContext(ctx, levelQuery, reservation.VariantID, reservation.LocationID, -onHandChange, reservation.Quantity)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, insertMovementQuery,
+			reservation.VariantID,
+			reservation.LocationID,
+			nil,
+			reservation.ID,
+			kind,
+			onHandChange,
+			-reservation.Quantity,
+			reservation.Reference,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		reservation.Status = status
+	}
+
+	return nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanLevel reads a stock record selected with its variant_id, location_id, on_hand, reserved and updated_at
+func scanLevel(row scanner) (*Level, error) {
+	var level Level
+
+	err := row.Scan(
+		&level.VariantID,
+		&level.LocationID,
+		&level.OnHand,
+		&level.Reserved,
+		&level.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	level.Available = level.OnHand - level.Reserved
+
+	return &level, nil
+}
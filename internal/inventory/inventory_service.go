@@ -0,0 +1,309 @@
+package inventory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/internal/location"
+	"github.com/aslam-ep/go-e-commerce/internal/variant"
+)
+
+// Service interface for the inventory service
+type Service interface {
+	// GetStock Retrieves the stock of a variant of the vendor at each of its locations.
+	GetStock(c context.Context, vendorID int, variantID int) (*Stock, error)
+
+	// AdjustStock Changes the on hand stock of a variant of the vendor at a location and returns the stock there.
+	AdjustStock(c context.Context, req *AdjustStockReq) (*Level, error)
+
+	// AddInitialStock Books the stock a new variant of the vendor is created with at a location of the vendor, the
+	// variant domain stocks the variants it creates through it.
+	AddInitialStock(c context.Context, vendorID int64, variantID int64, locationID int64, quantity int) error
+
+	// RemoveVariantStock Writes off the stock of a variant of the vendor at all of its locations before the variant is
+	// deleted, the variant domain deletes the variants through it. Returns variant.ErrVariantReserved if checkouts hold its stock.
+	RemoveVariantStock(c context.Context, vendorID int64, variantID int64) error
+
+	// TransferStock Moves available stock of a variant of the vendor between its locations and returns the stock at both ends.
+	TransferStock(c context.Context, req *TransferStockReq) (*TransferStockRes, error)
+
+	// ListMovements Retrieves a page of the stock ledger of a variant of the vendor.
+	ListMovements(c context.Context, req *ListMovementsReq) (*ListMovementsRes, error)
+
+	// Reserve Holds the stock of the items of a checkout for the reservation TTL at the locations chosen by the
+	// allocation strategy and returns the reservations.
+	Reserve(c context.Context, req *ReserveReq) (*ReservationsRes, error)
+
+	// CommitReservations Takes the stock held for a checkout off the on hand stock and returns the reservations.
+	CommitReservations(c context.Context, reference string) (*ReservationsRes, error)
+
+	// ReleaseReservations Gives the stock held for a checkout back and returns the reservations.
+	ReleaseReservations(c context.Context, reference string) (*ReservationsRes, error)
+}
+
+// ErrVariantNotFound is returned when the variant doesn't exist or belongs to another vendor.
+var ErrVariantNotFound = errors.New("variant not found")
+
+// ErrLocationNotFound is returned when the location doesn't exist or belongs to another vendor.
+var ErrLocationNotFound = errors.New("location not found")
+
+// ErrInsufficientStock is returned when a variant doesn't have the quantity available, or when the on hand stock
+// would fall below the reserved one.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrStockReserved is returned when removing the stock of a variant which checkouts in progress hold.
+var ErrStockReserved = errors.New("stock is reserved by checkouts in progress")
+
+// ErrReferenceInUse is returned when reserving stock for a reference which already holds or sold stock.
+var ErrReferenceInUse = errors.New("reference already has reservations")
+
+// ErrReservationNotFound is returned when the reference has no active reservations.
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// ErrReservationExpired is returned when committing a reservation which expired, its stock is given back.
+var ErrReservationExpired = errors.New("reservation expired")
+
+type service struct {
+	inventoryRepo Repository
+	variantRepo   variant.Repository
+	locationRepo  location.Repository
+	allocator     Allocator
+	timeout       time.Duration
+}
+
+// NewService initialize and return the Service, the allocator is used for the reservations which don't choose a strategy
+func NewService(ir Repository, vr variant.Repository, lr location.Repository, allocator Allocator) Service {
+	return &service{
+		inventoryRepo: ir,
+		variantRepo:   vr,
+		locationRepo:  lr,
+		allocator:     allocator,
+		timeout:       time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) GetStock(c context.Context, vendorID int, variantID int) (*Stock, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.checkVendorVariant(ctx, vendorID, variantID); err != nil {
+		return nil, err
+	}
+
+	// A variant without stock yet has no levels and nothing on hand
+	levels, err := s.inventoryRepo.GetLevels(ctx, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	stock := &Stock{VariantID: int64(variantID), Levels: levels}
+	for _, level := range levels {
+		stock.OnHand += level.OnHand
+		stock.Reserved += level.Reserved
+		stock.Available += level.Available
+	}
+
+	return stock, nil
+}
+
+func (s *service) AdjustStock(c context.Context, req *AdjustStockReq) (*Level, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.checkVendorVariant(ctx, int(req.VendorID), int(req.VariantID)); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVendorLocation(ctx, int(req.VendorID), int(req.LocationID)); err != nil {
+		return nil, err
+	}
+
+	return s.inventoryRepo.Adjust(ctx, int(req.VariantID), int(req.LocationID), req.Quantity, req.Reason, req.VendorID)
+}
+
+func (s *service) AddInitialStock(c context.Context, vendorID int64, variantID int64, locationID int64, quantity int) error {
+	_, err := s.AdjustStock(c, &AdjustStockReq{
+		VariantID:  variantID,
+		VendorID:   vendorID,
+		LocationID: locationID,
+		Quantity:   quantity,
+		Reason:     "initial stock",
+	})
+
+	return err
+}
+
+func (s *service) RemoveVariantStock(c context.Context, vendorID int64, variantID int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.checkVendorVariant(ctx, int(vendorID), int(variantID)); err != nil {
+		return err
+	}
+
+	err := s.inventoryRepo.Clear(ctx, int(variantID), "variant deleted", vendorID)
+	if errors.Is(err, ErrStockReserved) {
+		return variant.ErrVariantReserved
+	}
+
+	return err
+}
+
+func (s *service) TransferStock(c context.Context, req *TransferStockReq) (*TransferStockRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.checkVendorVariant(ctx, int(req.VendorID), int(req.VariantID)); err != nil {
+		return nil, err
+	}
+
+	for _, locationID := range []int64{req.FromLocationID, req.ToLocationID} {
+		if err := s.checkVendorLocation(ctx, int(req.VendorID), int(locationID)); err != nil {
+			return nil, err
+		}
+	}
+
+	from, to, err := s.inventoryRepo.Transfer(ctx, int(req.VariantID), int(req.FromLocationID), int(req.ToLocationID),
+		req.Quantity, req.Reason, req.VendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferStockRes{From: from, To: to}, nil
+}
+
+func (s *service) ListMovements(c context.Context, req *ListMovementsReq) (*ListMovementsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.checkVendorVariant(ctx, int(req.VendorID), int(req.VariantID)); err != nil {
+		return nil, err
+	}
+
+	movements, total, err := s.inventoryRepo.ListMovements(ctx, int(req.VariantID), req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListMovementsRes{
+		Movements: movements,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		Total:     total,
+	}
+
+	return res, nil
+}
+
+func (s *service) Reserve(c context.Context, req *ReserveReq) (*ReservationsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// The quantities of the same variant are allocated together, the variants in the order of their ids
+	quantities := make(map[int64]int)
+	for _, item := range req.Items {
+		quantities[item.VariantID] += item.Quantity
+	}
+	items := make([]*ReserveItem, 0, len(quantities))
+	for variantID, quantity := range quantities {
+		items = append(items, &ReserveItem{VariantID: variantID, Quantity: quantity})
+	}
+	slices.SortFunc(items, func(a, b *ReserveItem) int {
+		return cmp.Compare(a.VariantID, b.VariantID)
+	})
+
+	variantIDs := make([]int64, len(items))
+	for i, item := range items {
+		variantIDs[i] = item.VariantID
+	}
+
+	allocator := s.allocator
+	if req.Strategy != "" {
+		var err error
+		allocator, err = NewAllocator(req.Strategy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt := time.Now().Add(time.Duration(config.AppConfig.ReservationMinutes) * time.Minute)
+
+	reservations, err := s.inventoryRepo.Reserve(ctx, req.Reference, variantIDs, expiresAt, func(candidates []*Candidate) ([]*Allocation, error) {
+		return allocator.Allocate(items, candidates, req.Destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReservationsRes{
+		Reference:    req.Reference,
+		Reservations: reservations,
+	}
+
+	return res, nil
+}
+
+func (s *service) CommitReservations(c context.Context, reference string) (*ReservationsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	reservations, err := s.inventoryRepo.Commit(ctx, reference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReservationsRes{
+		Reference:    reference,
+		Reservations: reservations,
+	}
+
+	return res, nil
+}
+
+func (s *service) ReleaseReservations(c context.Context, reference string) (*ReservationsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	reservations, err := s.inventoryRepo.Release(ctx, reference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReservationsRes{
+		Reference:    reference,
+		Reservations: reservations,
+	}
+
+	return res, nil
+}
+
+// checkVendorVariant returns ErrVariantNotFound unless the variant belongs to the vendor.
+func (s *service) checkVendorVariant(ctx context.Context, vendorID int, variantID int) error {
+	v, err := s.variantRepo.GetByID(ctx, variantID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && v.VendorID != int64(vendorID)) {
+		return ErrVariantNotFound
+	}
+
+	return err
+}
+
+// checkVendorLocation returns ErrLocationNotFound unless the location belongs to the vendor.
+func (s *service) checkVendorLocation(ctx context.Context, vendorID int, locationID int) error {
+	l, err := s.locationRepo.GetByID(ctx, locationID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && l.VendorID != int64(vendorID)) {
+		return ErrLocationNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+// sweepBatchSize is the most reservations released in a single transaction
+const sweepBatchSize = 500
+
+// Sweeper periodically releases the expired reservations, giving their stock back.
+type Sweeper struct {
+	inventoryRepo Repository
+	interval      time.Duration
+	timeout       time.Duration
+}
+
+// NewSweeper initialize and return the Sweeper
+func NewSweeper(ir Repository, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		inventoryRepo: ir,
+		interval:      interval,
+		timeout:       time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+// Run releases the expired reservations right away and then on every interval, until the context is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sweeper) sweep(c context.Context) {
+	// A full batch means more are waiting, they are released right away
+	for {
+		released, err := s.release(c)
+		if err != nil {
+			log.Printf("Failed to release expired reservations: %v", err)
+			return
+		}
+
+		if released > 0 {
+			log.Printf("Released %d expired reservations", released)
+		}
+		if released < sweepBatchSize {
+			return
+		}
+	}
+}
+
+func (s *Sweeper) release(c context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.inventoryRepo.ReleaseExpired(ctx, sweepBatchSize)
+}
@@ -0,0 +1,56 @@
+package location
+
+import "time"
+
+// Location represents a warehouse a vendor ships from. The locations of higher priority are preferred,
+// the coordinates let the nearest one be chosen and an inactive location isn't shipped from.
+type Location struct {
+	ID           int64     `json:"id"`
+	VendorID     int64     `json:"vendor_id"`
+	Name         string    `json:"name"`
+	AddressLine1 string    `json:"address_line1"`
+	AddressLine2 string    `json:"address_line2"`
+	City         string    `json:"city"`
+	Region       string    `json:"region"`
+	PostalCode   string    `json:"postal_code"`
+	Country      string    `json:"country"`
+	Latitude     *float64  `json:"latitude"`
+	Longitude    *float64  `json:"longitude"`
+	Priority     int       `json:"priority"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateLocationReq represents the request payload for adding a location of a vendor, the country is an ISO 3166 code.
+type CreateLocationReq struct {
+	VendorID     int64    `json:"vendor_id"`
+	Name         string   `json:"name" validate:"required,max=255"`
+	AddressLine1 string   `json:"address_line1" validate:"required,max=255"`
+	AddressLine2 string   `json:"address_line2" validate:"max=255"`
+	City         string   `json:"city" validate:"required,max=100"`
+	Region       string   `json:"region" validate:"max=100"`
+	PostalCode   string   `json:"postal_code" validate:"max=20"`
+	Country      string   `json:"country" validate:"required,len=2,alpha"`
+	Latitude     *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Priority     int      `json:"priority"`
+}
+
+// UpdateLocationReq represents the request payload for updating a location of a vendor, the location stays
+// active or inactive when active is left out.
+type UpdateLocationReq struct {
+	ID           int64    `json:"id" validate:"required"`
+	VendorID     int64    `json:"vendor_id"`
+	Name         string   `json:"name" validate:"required,max=255"`
+	AddressLine1 string   `json:"address_line1" validate:"required,max=255"`
+	AddressLine2 string   `json:"address_line2" validate:"max=255"`
+	City         string   `json:"city" validate:"required,max=100"`
+	Region       string   `json:"region" validate:"max=100"`
+	PostalCode   string   `json:"postal_code" validate:"max=20"`
+	Country      string   `json:"country" validate:"required,len=2,alpha"`
+	Latitude     *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Priority     int      `json:"priority"`
+	Active       *bool    `json:"active"`
+}
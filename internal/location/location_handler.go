@@ -0,0 +1,231 @@
+package location
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler struct to hold the location service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the location Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// ListLocations godoc
+// @Summary      List Locations
+// @Description  List the warehouses of the vendor, the preferred ones first
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path  int  true  "Vendor user ID"
+// @Success      200  {array}   Location
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/locations [get]
+func (h *Handler) ListLocations(w http.ResponseWriter, r *http.Request) {
+	vendorIDstr := chi.URLParam(r, "user_id")
+	vendorID, err := strconv.Atoi(vendorIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ListLocations(r.Context(), vendorID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetLocation   godoc
+// @Summary      Get Location
+// @Description  Get a warehouse of the vendor by provided ID in url
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id      path  int  true  "Vendor user ID"
+// @Param        location_id  path  int  true  "Location ID"
+// @Success      200  {object}  Location
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/locations/{location_id} [get]
+func (h *Handler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	vendorID, locationID, err := readVendorLocationIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetLocation(r.Context(), vendorID, locationID)
+	if errors.Is(err, ErrLocationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// CreateLocation godoc
+// @Summary      Create Location
+// @Description  Add a warehouse of the vendor with its address and priority
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path  int  true  "Vendor user ID"
+// @Param        body  body  CreateLocationReq  true  "Location create request"
+// @Success      201  {object}  Location
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/locations [post]
+func (h *Handler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	vendorIDstr := chi.URLParam(r, "user_id")
+	vendorID, err := strconv.Atoi(vendorIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var createLocationReq CreateLocationReq
+	if err := utils.ReadFromRequest(r, &createLocationReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	createLocationReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(createLocationReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CreateLocation(r.Context(), &createLocationReq)
+	if errors.Is(err, ErrLocationNameTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// UpdateLocation godoc
+// @Summary      Update Location
+// @Description  Update a warehouse of the vendor by provided ID in url and details in body, an inactive one isn't shipped from
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id      path  int  true  "Vendor user ID"
+// @Param        location_id  path  int  true  "Location ID"
+// @Param        body  body  UpdateLocationReq  true  "Location update request"
+// @Success      200  {object}  Location
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/locations/{location_id} [put]
+func (h *Handler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	vendorID, locationID, err := readVendorLocationIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var updateLocationReq UpdateLocationReq
+	if err := utils.ReadFromRequest(r, &updateLocationReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	updateLocationReq.ID = int64(locationID)
+	updateLocationReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(updateLocationReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.UpdateLocation(r.Context(), &updateLocationReq)
+	if errors.Is(err, ErrLocationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrLocationNameTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// DeleteLocation godoc
+// @Summary      Delete Location
+// @Description  Delete a warehouse of the vendor which never had stock, the others can be deactivated instead
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id      path  int  true  "Vendor user ID"
+// @Param        location_id  path  int  true  "Location ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/locations/{location_id} [delete]
+func (h *Handler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	vendorID, locationID, err := readVendorLocationIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.DeleteLocation(r.Context(), vendorID, locationID)
+	if errors.Is(err, ErrLocationNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrLocationInUse) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// readVendorLocationIDs reads the vendor and location ids from the url.
+func readVendorLocationIDs(r *http.Request) (int, int, error) {
+	vendorID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	locationID, err := strconv.Atoi(chi.URLParam(r, "location_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return vendorID, locationID, nil
+}
@@ -0,0 +1,185 @@
+package location
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Repository interface for the location repository
+type Repository interface {
+	// Create stores a new location and returns the created location, returns ErrLocationNameTaken if the vendor
+	// has a location of the same name.
+	Create(ctx context.Context, location *Location) (*Location, error)
+
+	// GetByID find and returns the location by location id
+	GetByID(ctx context.Context, id int) (*Location, error)
+
+	// ListByVendor returns the locations of the vendor, the preferred ones first
+	ListByVendor(ctx context.Context, vendorID int) ([]*Location, error)
+
+	// Update updates the location of the vendor and returns the updated location, returns sql.ErrNoRows if the vendor
+	// has no such location and ErrLocationNameTaken if the vendor has another location of the same name.
+	Update(ctx context.Context, location *Location) (*Location, error)
+
+	// Delete removes the location of the vendor, returns sql.ErrNoRows if the vendor has no such location
+	// and ErrLocationInUse if it has stock records.
+	Delete(ctx context.Context, id int, vendorID int) error
+}
+
+// selectColumns are the columns read by scanLocation, in its order
+const selectColumns = `id, vendor_id, name, address_line1, address_line2, city, region, postal_code, country,
+	latitude, longitude, priority, active, created_at, updated_at`
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, location *Location) (*Location, error) {
+	insertQuery := `INSERT INTO locations(vendor_id, name, address_line1, address_line2, city, region, postal_code, country,
+		latitude, longitude, priority) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + selectColumns
+
+	createdLocation, err := scanLocation(r.db.QueryRowContext(ctx, insertQuery,
+		location.VendorID,
+		location.Name,
+		location.AddressLine1,
+		location.AddressLine2,
+		location.City,
+		location.Region,
+		location.PostalCode,
+		location.Country,
+		location.Latitude,
+		location.Longitude,
+		location.Priority,
+	))
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrLocationNameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return createdLocation, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*Location, error) {
+	selectQueryByID := `SELECT ` + selectColumns + ` FROM locations WHERE id = $1`
+
+	return scanLocation(r.db.QueryRowContext(ctx, selectQueryByID, id))
+}
+
+func (r *repository) ListByVendor(ctx context.Context, vendorID int) ([]*Location, error) {
+	selectQuery := `SELECT ` + selectColumns + ` FROM locations WHERE vendor_id = $1 ORDER BY priority DESC, id`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []*Location{}
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+func (r *repository) Update(ctx context.Context, location *Location) (*Location, error) {
+	updateQuery := `UPDATE locations SET name = $1, address_line1 = $2, address_line2 = $3, city = $4, region = $5,
+		postal_code = $6, country = $7, latitude = $8, longitude = $9, priority = $10, active = $11, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $12 AND vendor_id = $13 RETURNING ` + selectColumns
+
+	updatedLocation, err := scanLocation(r.db.QueryRowContext(ctx, updateQuery,
+		location.Name,
+		location.AddressLine1,
+		location.AddressLine2,
+		location.City,
+		location.Region,
+		location.PostalCode,
+		location.Country,
+		location.Latitude,
+		location.Longitude,
+		location.Priority,
+		location.Active,
+		location.ID,
+		location.VendorID,
+	))
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrLocationNameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedLocation, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int, vendorID int) error {
+	deleteQuery := `DELETE FROM locations WHERE id = $1 AND vendor_id = $2`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, id, vendorID)
+	if utils.IsForeignKeyViolation(err) {
+		return ErrLocationInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanLocation reads a location selected with selectColumns
+func scanLocation(row scanner) (*Location, error) {
+	var location Location
+
+	err := row.Scan(
+		&location.ID,
+		&location.VendorID,
+		&location.Name,
+		&location.AddressLine1,
+		&location.AddressLine2,
+		&location.City,
+		&location.Region,
+		&location.PostalCode,
+		&location.Country,
+		&location.Latitude,
+		&location.Longitude,
+		&location.Priority,
+		&location.Active,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &location, nil
+}
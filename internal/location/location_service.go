@@ -0,0 +1,159 @@
+package location
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Service interface for the location service
+type Service interface {
+	// ListLocations Retrieves the locations of the vendor, the preferred ones first.
+	ListLocations(c context.Context, vendorID int) ([]*Location, error)
+
+	// GetLocation Retrieves a location of the vendor by its ID.
+	GetLocation(c context.Context, vendorID int, id int) (*Location, error)
+
+	// CreateLocation Creates a new location of the vendor and returns it.
+	CreateLocation(c context.Context, req *CreateLocationReq) (*Location, error)
+
+	// UpdateLocation Updates a location of the vendor and returns it.
+	UpdateLocation(c context.Context, req *UpdateLocationReq) (*Location, error)
+
+	// DeleteLocation Deletes a location of the vendor without stock records and returns a message indicating success or failure.
+	DeleteLocation(c context.Context, vendorID int, id int) (*utils.MessageRes, error)
+}
+
+// ErrLocationNotFound is returned when the location doesn't exist or belongs to another vendor.
+var ErrLocationNotFound = errors.New("location not found")
+
+// ErrLocationNameTaken is returned when the vendor has another location of the same name.
+var ErrLocationNameTaken = errors.New("location name already in use")
+
+// ErrLocationInUse is returned when deleting a location which has stock records, it can be deactivated instead.
+var ErrLocationInUse = errors.New("location has stock records, deactivate it instead")
+
+type service struct {
+	locationRepo Repository
+	timeout      time.Duration
+}
+
+// NewService initialize and return the Service
+func NewService(lr Repository) Service {
+	return &service{
+		locationRepo: lr,
+		timeout:      time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) ListLocations(c context.Context, vendorID int) ([]*Location, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.locationRepo.ListByVendor(ctx, vendorID)
+}
+
+func (s *service) GetLocation(c context.Context, vendorID int, id int) (*Location, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	location, err := s.locationRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && location.VendorID != int64(vendorID)) {
+		return nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+func (s *service) CreateLocation(c context.Context, req *CreateLocationReq) (*Location, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	location := &Location{
+		VendorID:     req.VendorID,
+		Name:         req.Name,
+		AddressLine1: req.AddressLine1,
+		AddressLine2: req.AddressLine2,
+		City:         req.City,
+		Region:       req.Region,
+		PostalCode:   req.PostalCode,
+		Country:      strings.ToUpper(req.Country),
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Priority:     req.Priority,
+	}
+
+	return s.locationRepo.Create(ctx, location)
+}
+
+func (s *service) UpdateLocation(c context.Context, req *UpdateLocationReq) (*Location, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	current, err := s.locationRepo.GetByID(ctx, int(req.ID))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && current.VendorID != req.VendorID) {
+		return nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	active := current.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	location := &Location{
+		ID:           req.ID,
+		VendorID:     req.VendorID,
+		Name:         req.Name,
+		AddressLine1: req.AddressLine1,
+		AddressLine2: req.AddressLine2,
+		City:         req.City,
+		Region:       req.Region,
+		PostalCode:   req.PostalCode,
+		Country:      strings.ToUpper(req.Country),
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Priority:     req.Priority,
+		Active:       active,
+	}
+
+	updatedLocation, err := s.locationRepo.Update(ctx, location)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedLocation, nil
+}
+
+func (s *service) DeleteLocation(c context.Context, vendorID int, id int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	err := s.locationRepo.Delete(ctx, id, vendorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Location deleted.",
+	}
+
+	return res, nil
+}
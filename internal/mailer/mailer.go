@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"log"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+// Message represents a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer interface for delivering emails
+type Mailer interface {
+	// Send delivers the message to its recipient.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New initialize and return the Mailer of the configured driver
+func New() Mailer {
+	switch config.AppConfig.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(
+			config.AppConfig.SMTPHost,
+			config.AppConfig.SMTPPort,
+			config.AppConfig.SMTPUsername,
+			config.AppConfig.SMTPPassword,
+			config.AppConfig.MailFrom,
+		)
+	case "file":
+		return NewFileMailer(config.AppConfig.MailFileDir, config.AppConfig.MailFrom)
+	case "log":
+		return NewLogMailer()
+	default:
+		log.Printf("Unknown mail driver %q, falling back to log driver", config.AppConfig.MailDriver)
+		return NewLogMailer()
+	}
+}
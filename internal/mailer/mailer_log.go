@@ -0,0 +1,18 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+type logMailer struct{}
+
+// NewLogMailer initialize and return a Mailer which writes the emails to the log, for local use
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(_ context.Context, msg *Message) error {
+	log.Printf("Mail to: %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,83 @@
+package product
+
+import "time"
+
+// Product statuses, only the active products are shown to the shoppers
+const (
+	StatusDraft    = "draft"
+	StatusActive   = "active"
+	StatusArchived = "archived"
+)
+
+// Product represents a product sold by a vendor.
+// The price is in the minor units of the currency, like cents, so it's never rounded.
+type Product struct {
+	ID          int64     `json:"id"`
+	VendorID    int64     `json:"vendor_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Slug        string    `json:"slug"`
+	Price       int64     `json:"price"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CategoryIDs []int64   `json:"category_ids"`
+}
+
+// CreateProductReq represents the request payload for creating a product.
+// Without a slug one is derived from the title, without a status the product is a draft.
+type CreateProductReq struct {
+	VendorID    int64  `json:"vendor_id"`
+	Title       string `json:"title" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=10000"`
+	Slug        string `json:"slug" validate:"max=255"`
+	Price       int64  `json:"price" validate:"min=0"`
+	Currency    string `json:"currency" validate:"required,len=3,alpha"`
+	Status      string `json:"status" validate:"omitempty,oneof=draft active"`
+}
+
+// UpdateProductReq represents the request payload for updating a product of a vendor.
+type UpdateProductReq struct {
+	ID          int64  `json:"id" validate:"required"`
+	VendorID    int64  `json:"vendor_id"`
+	Title       string `json:"title" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=10000"`
+	Slug        string `json:"slug" validate:"required,max=255"`
+	Price       int64  `json:"price" validate:"min=0"`
+	Currency    string `json:"currency" validate:"required,len=3,alpha"`
+	Status      string `json:"status" validate:"required,oneof=draft active archived"`
+}
+
+// ListProductsReq represents the filters, sorting and pagination of a product listing.
+// The public listing only has the active products, a vendor lists their own products in any status.
+// A category lists the products of its descendants too.
+type ListProductsReq struct {
+	Page       int    `json:"page" validate:"min=1"`
+	PageSize   int    `json:"page_size" validate:"min=1,max=100"`
+	Search     string `json:"search" validate:"max=255"`
+	VendorID   int64  `json:"vendor_id"`
+	Status     string `json:"status" validate:"omitempty,oneof=draft active archived"`
+	MinPrice   *int64 `json:"min_price"`
+	MaxPrice   *int64 `json:"max_price"`
+	Currency   string `json:"currency" validate:"omitempty,len=3,alpha"`
+	CategoryID int64  `json:"category_id"`
+	SortBy     string `json:"sort_by" validate:"oneof=id title price created_at updated_at"`
+	SortOrder  string `json:"sort_order" validate:"oneof=asc desc"`
+}
+
+// SetProductCategoriesReq represents the request payload for replacing the categories of a product of a vendor,
+// an empty list unassigns the product from every category.
+type SetProductCategoriesReq struct {
+	ID          int64   `json:"id" validate:"required"`
+	VendorID    int64   `json:"vendor_id"`
+	CategoryIDs []int64 `json:"category_ids" validate:"required,max=50,unique,dive,min=1"`
+}
+
+// ListProductsRes represents a page of a product listing.
+type ListProductsRes struct {
+	Products []*Product `json:"products"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+	Total    int        `json:"total"`
+}
@@ -0,0 +1,458 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler struct to hold the product service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the product Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// ListProducts  godoc
+// @Summary      List Products
+// @Description  List the active products page by page, with search, filters and sorting
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        page         query  int     false  "Page number, starting at 1"  default(1)
+// @Param        page_size    query  int     false  "Products per page, at most 100"  default(20)
+// @Param        search       query  string  false  "Search in title and description"
+// @Param        vendor_id    query  int     false  "Vendor of the products"
+// @Param        min_price    query  int     false  "Lowest price, in minor units"
+// @Param        max_price    query  int     false  "Highest price, in minor units"
+// @Param        currency     query  string  false  "ISO 4217 currency code"
+// @Param        category_id  query  int     false  "Category of the products, its descendants included"
+// @Param        sort_by      query  string  false  "Sort field"  Enums(id, title, price, created_at, updated_at)  default(id)
+// @Param        sort_order   query  string  false  "Sort order"  Enums(asc, desc)  default(asc)
+// @Success      200  {object}  ListProductsRes
+// @Failure      400  {object}  utils.MessageRes
+// @Router       /products [get]
+func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	listProductsReq, err := parseListProductsReq(r.URL.Query())
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Shoppers only see the active products
+	listProductsReq.Status = StatusActive
+
+	if err := utils.Validate.Struct(listProductsReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ListProducts(r.Context(), listProductsReq)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetProduct    godoc
+// @Summary      Get Product
+// @Description  Get an active product by provided ID in url
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        product_id  path  int  true  "Product ID"
+// @Success      200  {object}  Product
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /products/{product_id} [get]
+func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	productIDstr := chi.URLParam(r, "product_id")
+	productID, err := strconv.Atoi(productIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetProduct(r.Context(), productID)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetProductBySlug godoc
+// @Summary      Get Product By Slug
+// @Description  Get an active product by provided slug in url
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        slug  path  string  true  "Product slug"
+// @Success      200  {object}  Product
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /products/slug/{slug} [get]
+func (h *Handler) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.GetProductBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ListVendorProducts godoc
+// @Summary      List Vendor Products
+// @Description  List the products of the vendor in any status page by page, with search, filters and sorting
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id      path   int     true   "Vendor user ID"
+// @Param        page         query  int     false  "Page number, starting at 1"  default(1)
+// @Param        page_size    query  int     false  "Products per page, at most 100"  default(20)
+// @Param        search       query  string  false  "Search in title and description"
+// @Param        status       query  string  false  "Status of the products"  Enums(draft, active, archived)
+// @Param        min_price    query  int     false  "Lowest price, in minor units"
+// @Param        max_price    query  int     false  "Highest price, in minor units"
+// @Param        currency     query  string  false  "ISO 4217 currency code"
+// @Param        category_id  query  int     false  "Category of the products, its descendants included"
+// @Param        sort_by      query  string  false  "Sort field"  Enums(id, title, price, created_at, updated_at)  default(id)
+// @Param        sort_order   query  string  false  "Sort order"  Enums(asc, desc)  default(asc)
+// @Success      200  {object}  ListProductsRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products [get]
+func (h *Handler) ListVendorProducts(w http.ResponseWriter, r *http.Request) {
+	vendorIDstr := chi.URLParam(r, "user_id")
+	vendorID, err := strconv.Atoi(vendorIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	listProductsReq, err := parseListProductsReq(r.URL.Query())
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	listProductsReq.VendorID = int64(vendorID)
+	listProductsReq.Status = r.URL.Query().Get("status")
+
+	if err := utils.Validate.Struct(listProductsReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ListProducts(r.Context(), listProductsReq)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetVendorProduct godoc
+// @Summary      Get Vendor Product
+// @Description  Get a product of the vendor in any status by provided ID in url
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Success      200  {object}  Product
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id} [get]
+func (h *Handler) GetVendorProduct(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetVendorProduct(r.Context(), vendorID, productID)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// CreateProduct godoc
+// @Summary      Create Product
+// @Description  Create a product of the vendor, a draft unless the status is active. Without a slug one is derived from the title
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path  int  true  "Vendor user ID"
+// @Param        body  body  CreateProductReq  true  "Product create request"
+// @Success      201  {object}  Product
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products [post]
+func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	vendorIDstr := chi.URLParam(r, "user_id")
+	vendorID, err := strconv.Atoi(vendorIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var createProductReq CreateProductReq
+	if err := utils.ReadFromRequest(r, &createProductReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	createProductReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(createProductReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CreateProduct(r.Context(), &createProductReq)
+	if errors.Is(err, ErrSlugTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// UpdateProduct godoc
+// @Summary      Update Product
+// @Description  Update a product of the vendor by provided ID in url and details in body
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        body  body  UpdateProductReq  true  "Product update request"
+// @Success      200  {object}  Product
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id} [put]
+func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var updateProductReq UpdateProductReq
+	if err := utils.ReadFromRequest(r, &updateProductReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	updateProductReq.ID = int64(productID)
+	updateProductReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(updateProductReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.UpdateProduct(r.Context(), &updateProductReq)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrSlugTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// ArchiveProduct godoc
+// @Summary      Archive Product
+// @Description  Archive a product of the vendor, hiding it from the shoppers
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/archive [post]
+func (h *Handler) ArchiveProduct(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ArchiveProduct(r.Context(), vendorID, productID)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// SetProductCategories godoc
+// @Summary      Set Product Categories
+// @Description  Replace the categories of a product of the vendor, an empty list unassigns it from every category
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        body  body  SetProductCategoriesReq  true  "Product categories request"
+// @Success      200  {object}  Product
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/categories [put]
+func (h *Handler) SetProductCategories(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var setProductCategoriesReq SetProductCategoriesReq
+	if err := utils.ReadFromRequest(r, &setProductCategoriesReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setProductCategoriesReq.ID = int64(productID)
+	setProductCategoriesReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(setProductCategoriesReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SetProductCategories(r.Context(), &setProductCategoriesReq)
+	if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrCategoryNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// readVendorProductIDs reads the vendor and product ids from the url.
+func readVendorProductIDs(r *http.Request) (int, int, error) {
+	vendorID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return vendorID, productID, nil
+}
+
+// parseListProductsReq reads the product listing filters from the query string, with the defaults for the missing ones.
+func parseListProductsReq(query url.Values) (*ListProductsReq, error) {
+	req := &ListProductsReq{
+		Page:      1,
+		PageSize:  20,
+		Search:    query.Get("search"),
+		Currency:  query.Get("currency"),
+		SortBy:    "id",
+		SortOrder: "asc",
+	}
+
+	var err error
+	if page := query.Get("page"); page != "" {
+		if req.Page, err = strconv.Atoi(page); err != nil {
+			return nil, fmt.Errorf("invalid page: %v", err)
+		}
+	}
+
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		if req.PageSize, err = strconv.Atoi(pageSize); err != nil {
+			return nil, fmt.Errorf("invalid page_size: %v", err)
+		}
+	}
+
+	if vendorID := query.Get("vendor_id"); vendorID != "" {
+		if req.VendorID, err = strconv.ParseInt(vendorID, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid vendor_id: %v", err)
+		}
+	}
+
+	if categoryID := query.Get("category_id"); categoryID != "" {
+		if req.CategoryID, err = strconv.ParseInt(categoryID, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid category_id: %v", err)
+		}
+	}
+
+	if minPrice := query.Get("min_price"); minPrice != "" {
+		price, err := strconv.ParseInt(minPrice, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_price: %v", err)
+		}
+		req.MinPrice = &price
+	}
+
+	if maxPrice := query.Get("max_price"); maxPrice != "" {
+		price, err := strconv.ParseInt(maxPrice, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_price: %v", err)
+		}
+		req.MaxPrice = &price
+	}
+
+	if sortBy := query.Get("sort_by"); sortBy != "" {
+		req.SortBy = sortBy
+	}
+
+	if sortOrder := query.Get("sort_order"); sortOrder != "" {
+		req.SortOrder = sortOrder
+	}
+
+	return req, nil
+}
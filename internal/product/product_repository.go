@@ -0,0 +1,293 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/lib/pq"
+)
+
+// Repository interface for the product repository
+type Repository interface {
+	// Create stores a new product and returns the created product, returns ErrSlugTaken if the slug is in use.
+	Create(ctx context.Context, product *Product) (*Product, error)
+
+	// GetByID find and returns the product by product id
+	GetByID(ctx context.Context, id int) (*Product, error)
+
+	// GetBySlug find and returns the product by product slug
+	GetBySlug(ctx context.Context, slug string) (*Product, error)
+
+	// List returns a page of the products matching the filters along with the total count of matching products
+	List(ctx context.Context, filter *ListProductsReq) ([]*Product, int, error)
+
+	// Update updates the product of the vendor and returns the updated product, returns sql.ErrNoRows if the vendor
+	// has no such product and ErrSlugTaken if the slug is in use.
+	Update(ctx context.Context, product *Product) (*Product, error)
+
+	// SetStatus changes the status of the product of the vendor, returns sql.ErrNoRows if the vendor has no such product.
+	SetStatus(ctx context.Context, id int, vendorID int, status string) error
+
+	// SetCategories replaces the categories of the product of the vendor, returns sql.ErrNoRows if the vendor
+	// has no such product and ErrCategoryNotFound if a category doesn't exist.
+	SetCategories(ctx context.Context, id int, vendorID int, categoryIDs []int64) error
+}
+
+// selectColumns are the columns read by scanProduct, in its order
+const selectColumns = `id, vendor_id, title, description, slug, price, currency, status, created_at, updated_at,
+	ARRAY(SELECT category_id FROM product_categories WHERE product_id = products.id ORDER BY category_id)`
+
+// sortColumns maps the sortable fields of the product listing to their columns
+var sortColumns = map[string]string{
+	"id":         "id",
+	"title":      "title",
+	"price":      "price",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// likeEscaper escapes the LIKE wildcards so the search matches them literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, product *Product) (*Product, error) {
+	insertQuery := `INSERT INTO products(vendor_id, title, description, slug, price, currency, status) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		product.VendorID,
+		product.Title,
+		product.Description,
+		product.Slug,
+		product.Price,
+		product.Currency,
+		product.Status,
+	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	product.CategoryIDs = []int64{}
+
+	return product, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*Product, error) {
+	selectQueryByID := `SELECT ` + selectColumns + ` FROM products WHERE id = $1`
+
+	return scanProduct(r.db.QueryRowContext(ctx, selectQueryByID, id))
+}
+
+func (r *repository) GetBySlug(ctx context.Context, slug string) (*Product, error) {
+	selectQueryBySlug := `SELECT ` + selectColumns + ` FROM products WHERE slug = $1`
+
+	return scanProduct(r.db.QueryRowContext(ctx, selectQueryBySlug, slug))
+}
+
+func (r *repository) List(ctx context.Context, filter *ListProductsReq) ([]*Product, int, error) {
+	var conditions []string
+	var args []any
+
+	// addArg adds the value to the query arguments and returns its placeholder
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+addArg(filter.Status))
+	}
+
+	if filter.VendorID != 0 {
+		conditions = append(conditions, "vendor_id = "+addArg(filter.VendorID))
+	}
+
+	if filter.Search != "" {
+		search := addArg("%" + likeEscaper.Replace(filter.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf("(title ILIKE %[1]s OR description ILIKE %[1]s)", search))
+	}
+
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= "+addArg(*filter.MinPrice))
+	}
+
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= "+addArg(*filter.MaxPrice))
+	}
+
+	if filter.Currency != "" {
+		conditions = append(conditions, "currency = "+addArg(strings.ToUpper(filter.Currency)))
+	}
+
+	// A category has the products of its descendants too, they share its path as a prefix
+	if filter.CategoryID != 0 {
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM product_categories pc JOIN categories c ON c.id = pc.category_id
+			WHERE pc.product_id = products.id AND c.path LIKE (SELECT path FROM categories WHERE id = %s) || '%%')`, addArg(filter.CategoryID)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM products` + where
+
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortColumn, ok := sortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = "id"
+	}
+	sortOrder := "ASC"
+	if filter.SortOrder == "desc" {
+		sortOrder = "DESC"
+	}
+
+	// The id breaks the ties, so the pages don't overlap
+	selectQuery := `SELECT ` + selectColumns + ` FROM products` +
+		where +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, sortOrder, sortOrder) +
+		fmt.Sprintf(" LIMIT %s OFFSET %s", addArg(filter.PageSize), addArg((filter.Page-1)*filter.PageSize))
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+
+	return products, total, rows.Err()
+}
+
+func (r *repository) Update(ctx context.Context, product *Product) (*Product, error) {
+	updateQuery := `UPDATE products SET title = $1, description = $2, slug = $3, price = $4, currency = $5, status = $6,
+		updated_at = CURRENT_TIMESTAMP WHERE id = $7 AND vendor_id = $8
+		RETURNING created_at, updated_at, ARRAY(SELECT category_id FROM product_categories WHERE product_id = products.id ORDER BY category_id)`
+
+	err := r.db.QueryRowContext(ctx, updateQuery,
+		product.Title,
+		product.Description,
+		product.Slug,
+		product.Price,
+		product.Currency,
+		product.Status,
+		product.ID,
+		product.VendorID,
+	).Scan(&product.CreatedAt, &product.UpdatedAt, pq.Array(&product.CategoryIDs))
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (r *repository) SetStatus(ctx context.Context, id int, vendorID int, status string) error {
+	statusQuery := `UPDATE products SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND vendor_id = $3`
+
+	result, err := r.db.ExecContext(ctx, statusQuery, status, id, vendorID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) SetCategories(ctx context.Context, id int, vendorID int, categoryIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The product row is locked, so concurrent replacements don't mix their categories
+	var productID int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM products WHERE id = $1 AND vendor_id = $2 FOR UPDATE`, id, vendorID).Scan(&productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_categories WHERE product_id = $1`, productID)
+	if err != nil {
+		return err
+	}
+
+	insertQuery := `INSERT INTO product_categories(product_id, category_id) SELECT $1, unnest($2::INT[]) ON CONFLICT DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, insertQuery, productID, pq.Array(categoryIDs))
+	if utils.IsForeignKeyViolation(err) {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanProduct reads a product selected with selectColumns
+func scanProduct(row scanner) (*Product, error) {
+	var product Product
+
+	err := row.Scan(
+		&product.ID,
+		&product.VendorID,
+		&product.Title,
+		&product.Description,
+		&product.Slug,
+		&product.Price,
+		&product.Currency,
+		&product.Status,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+		pq.Array(&product.CategoryIDs),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
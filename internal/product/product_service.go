@@ -0,0 +1,244 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Service interface for the product service
+type Service interface {
+	// GetProduct Retrieves an active product by its ID.
+	GetProduct(c context.Context, id int) (*Product, error)
+
+	// GetProductBySlug Retrieves an active product by its slug.
+	GetProductBySlug(c context.Context, slug string) (*Product, error)
+
+	// ListProducts Retrieves a page of the products matching the filters of the request.
+	ListProducts(c context.Context, req *ListProductsReq) (*ListProductsRes, error)
+
+	// GetVendorProduct Retrieves a product of the vendor by its ID, in any status.
+	GetVendorProduct(c context.Context, vendorID int, id int) (*Product, error)
+
+	// CreateProduct Creates a new product of the vendor and returns it.
+	CreateProduct(c context.Context, req *CreateProductReq) (*Product, error)
+
+	// UpdateProduct Updates a product of the vendor and returns it.
+	UpdateProduct(c context.Context, req *UpdateProductReq) (*Product, error)
+
+	// ArchiveProduct Hides a product of the vendor from the shoppers and returns a message indicating success or failure.
+	ArchiveProduct(c context.Context, vendorID int, id int) (*utils.MessageRes, error)
+
+	// SetProductCategories Replaces the categories of a product of the vendor and returns the product.
+	SetProductCategories(c context.Context, req *SetProductCategoriesReq) (*Product, error)
+}
+
+// ErrProductNotFound is returned when the product doesn't exist, isn't active or belongs to another vendor.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrSlugTaken is returned when the slug belongs to another product.
+var ErrSlugTaken = errors.New("slug already in use")
+
+// ErrInvalidSlug is returned when the slug isn't made of lowercase letters and digits separated by single dashes.
+var ErrInvalidSlug = errors.New("slug must be lowercase letters and digits separated by single dashes")
+
+// ErrCategoryNotFound is returned when a category assigned to the product doesn't exist.
+var ErrCategoryNotFound = errors.New("category not found")
+
+type service struct {
+	productRepo Repository
+	timeout     time.Duration
+}
+
+// NewService initialize and return the Service
+func NewService(pr Repository) Service {
+	return &service{
+		productRepo: pr,
+		timeout:     time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) GetProduct(c context.Context, id int) (*Product, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	product, err := s.productRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && product.Status != StatusActive) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (s *service) GetProductBySlug(c context.Context, slug string) (*Product, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	product, err := s.productRepo.GetBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && product.Status != StatusActive) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (s *service) ListProducts(c context.Context, req *ListProductsReq) (*ListProductsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	products, total, err := s.productRepo.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListProductsRes{
+		Products: products,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Total:    total,
+	}
+
+	return res, nil
+}
+
+func (s *service) GetVendorProduct(c context.Context, vendorID int, id int) (*Product, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	product, err := s.productRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && product.VendorID != int64(vendorID)) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (s *service) CreateProduct(c context.Context, req *CreateProductReq) (*Product, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	slug := req.Slug
+	if slug == "" {
+		slug = utils.Slugify(req.Title, "product")
+	}
+	if !utils.IsSlug(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	status := req.Status
+	if status == "" {
+		status = StatusDraft
+	}
+
+	product := &Product{
+		VendorID:    req.VendorID,
+		Title:       req.Title,
+		Description: req.Description,
+		Slug:        slug,
+		Price:       req.Price,
+		Currency:    strings.ToUpper(req.Currency),
+		Status:      status,
+	}
+
+	createdProduct, err := s.productRepo.Create(ctx, product)
+
+	// A derived slug is made unique with a random suffix, a chosen one is left to the vendor to change
+	if errors.Is(err, ErrSlugTaken) && req.Slug == "" {
+		suffix, err := utils.GenerateRandomString(3)
+		if err != nil {
+			return nil, err
+		}
+		product.Slug = slug + "-" + suffix
+
+		return s.productRepo.Create(ctx, product)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return createdProduct, nil
+}
+
+func (s *service) UpdateProduct(c context.Context, req *UpdateProductReq) (*Product, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if !utils.IsSlug(req.Slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	product := &Product{
+		ID:          req.ID,
+		VendorID:    req.VendorID,
+		Title:       req.Title,
+		Description: req.Description,
+		Slug:        req.Slug,
+		Price:       req.Price,
+		Currency:    strings.ToUpper(req.Currency),
+		Status:      req.Status,
+	}
+
+	updatedProduct, err := s.productRepo.Update(ctx, product)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedProduct, nil
+}
+
+func (s *service) ArchiveProduct(c context.Context, vendorID int, id int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	err := s.productRepo.SetStatus(ctx, id, vendorID, StatusArchived)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Product archived.",
+	}
+
+	return res, nil
+}
+
+func (s *service) SetProductCategories(c context.Context, req *SetProductCategoriesReq) (*Product, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	err := s.productRepo.SetCategories(ctx, int(req.ID), int(req.VendorID), req.CategoryIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
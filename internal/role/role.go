@@ -0,0 +1,12 @@
+package role
+
+import "time"
+
+// Role represents a role and the permissions granted to the users having it.
+type Role struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
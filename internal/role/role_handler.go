@@ -0,0 +1,38 @@
+package role
+
+import (
+	"net/http"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// Handler struct to hold the role service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the role Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// GetRoles      godoc
+// @Summary      List Roles
+// @Description  List every role along with its permissions, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   Role
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /admin/roles [get]
+func (h *Handler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.GetRoles(r.Context())
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
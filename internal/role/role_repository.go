@@ -0,0 +1,102 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Repository interface for the role repository
+type Repository interface {
+	// GetByName find and returns the role along with its permissions, by role name
+	GetByName(ctx context.Context, name string) (*Role, error)
+
+	// GetAll returns every role along with its permissions
+	GetAll(ctx context.Context) ([]*Role, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetByName(ctx context.Context, name string) (*Role, error) {
+	var role Role
+	selectQueryByName := `SELECT id, name, description, created_at FROM roles WHERE name = $1`
+
+	err := r.db.QueryRowContext(ctx, selectQueryByName, name).Scan(
+		&role.ID,
+		&role.Name,
+		&role.Description,
+		&role.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	role.Permissions, err = r.getPermissions(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *repository) GetAll(ctx context.Context) ([]*Role, error) {
+	selectQuery := `SELECT id, name, description, created_at FROM roles ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*Role
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, &role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		role.Permissions, err = r.getPermissions(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return roles, nil
+}
+
+// getPermissions returns the names of the permissions granted to the role
+func (r *repository) getPermissions(ctx context.Context, roleID int64) ([]string, error) {
+	selectQuery := `SELECT p.name FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1 ORDER BY p.name`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
@@ -0,0 +1,34 @@
+package role
+
+import (
+	"context"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+// Service interface for the role service
+type Service interface {
+	// GetRoles Retrieves every role along with its permissions.
+	GetRoles(c context.Context) ([]*Role, error)
+}
+
+type service struct {
+	roleRepo Repository
+	timeout  time.Duration
+}
+
+// NewService initialize and return the Service
+func NewService(rr Repository) Service {
+	return &service{
+		roleRepo: rr,
+		timeout:  time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) GetRoles(c context.Context) ([]*Role, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.roleRepo.GetAll(ctx)
+}
@@ -0,0 +1,31 @@
+package sms
+
+import (
+	"context"
+	"log"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+// Message represents a text message.
+type Message struct {
+	To   string
+	Body string
+}
+
+// SMSSender interface for delivering text messages
+type SMSSender interface {
+	// Send delivers the message to its recipient.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New initialize and return the SMSSender of the configured driver
+func New() SMSSender {
+	switch config.AppConfig.SMSDriver {
+	case "log":
+		return NewLogSender()
+	default:
+		log.Printf("Unknown sms driver %q, falling back to log driver", config.AppConfig.SMSDriver)
+		return NewLogSender()
+	}
+}
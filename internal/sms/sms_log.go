@@ -0,0 +1,18 @@
+package sms
+
+import (
+	"context"
+	"log"
+)
+
+type logSender struct{}
+
+// NewLogSender initialize and return an SMSSender which writes the text messages to the log, for local use
+func NewLogSender() SMSSender {
+	return &logSender{}
+}
+
+func (s *logSender) Send(_ context.Context, msg *Message) error {
+	log.Printf("SMS to: %s\n\n%s", msg.To, msg.Body)
+	return nil
+}
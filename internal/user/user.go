@@ -14,14 +14,24 @@ type User struct {
 	Password  string    `json:"password,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	IsDeleted       bool       `json:"is_deleted,omitempty"`
 }
 
 // UpdateUserReq represents the request payload for updating user details.
+// The role is changed only by admins, through ChangeRoleReq. A changed phone has to be verified again.
 type UpdateUserReq struct {
-	ID    int64  `json:"id" validate:"required"`
-	Name  string `json:"name" validate:"required,min=3,max=100"`
-	Phone string `json:"phone" validate:"required,e164"`
-	Role  string `json:"role" validate:"required,oneof=user vendor"`
+	ID   int64  `json:"id" validate:"required"`
+	Name string `json:"name" validate:"required,min=3,max=100"`
+}
+
+// ChangeRoleReq represents the request payload for changing the role of a user.
+type ChangeRoleReq struct {
+	ID   int64  `json:"id" validate:"required"`
+	Role string `json:"role" validate:"required"`
 }
 
 // ResetPasswordReq represents the request payload for resetting a user's password.
@@ -29,4 +39,27 @@ type ResetPasswordReq struct {
 	ID              int64  `json:"id"`
 	CurrentPassword string `json:"current_password" validate:"required,min=6"`
 	NewPassword     string `json:"new_password" validate:"required,min=6"`
+	IP              string `json:"-"`
+}
+
+// ListUsersReq represents the filters, sorting and pagination of the admin user listing.
+// Deleted is one of false, true or all, with only the not deleted users listed by default.
+type ListUsersReq struct {
+	Page        int        `json:"page" validate:"min=1"`
+	PageSize    int        `json:"page_size" validate:"min=1,max=100"`
+	Search      string     `json:"search" validate:"max=255"`
+	Role        string     `json:"role" validate:"max=100"`
+	Deleted     string     `json:"deleted" validate:"oneof=false true all"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	SortBy      string     `json:"sort_by" validate:"oneof=id name email role created_at updated_at"`
+	SortOrder   string     `json:"sort_order" validate:"oneof=asc desc"`
+}
+
+// ListUsersRes represents a page of the admin user listing.
+type ListUsersRes struct {
+	Users    []*User `json:"users"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"page_size"`
+	Total    int     `json:"total"`
 }
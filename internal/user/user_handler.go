@@ -1,8 +1,12 @@
 package user
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/aslam-ep/go-e-commerce/utils"
 	"github.com/go-chi/chi/v5"
@@ -29,6 +33,7 @@ func NewHandler(s Service) *Handler {
 // @Param        id  path  int  true  "User ID"
 // @Success      200  {object}  User
 // @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
 // @Router       /users/{user_id} [post]
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	userIDstr := chi.URLParam(r, "user_id")
@@ -57,6 +62,7 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 // @Param        body  body  UpdateUserReq  true  "User Update request"
 // @Success      200  {object}  User
 // @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
 // @Router       /users/{user_id}/update [put]
 func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	userIDstr := chi.URLParam(r, "user_id")
@@ -87,9 +93,50 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	utils.WriteResponse(w, http.StatusOK, res)
 }
 
+// ChangeRole    godoc
+// @Summary      Change User Role
+// @Description  Change the role of the user by provided ID in url and role in body, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Param        body  body  ChangeRoleReq  true  "Role change request"
+// @Success      200  {object}  User
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /admin/users/{user_id}/role [put]
+func (h *Handler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var changeRoleReq ChangeRoleReq
+	if err := utils.ReadFromRequest(r, &changeRoleReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	changeRoleReq.ID = int64(userID)
+
+	if err := utils.Validate.Struct(changeRoleReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ChangeUserRole(r.Context(), &changeRoleReq)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
 // ChangePassword godoc
 // @Summary      Reset User Password
-// @Description  Reset User Password by provided ID in url and password in body
+// @Description  Reset User Password by provided ID in url and password in body, needs a login or re-authentication within the last 5 minutes, every session of the user is ended
 // @Tags         User
 // @Accept       json
 // @Produce      json
@@ -97,6 +144,9 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 // @Param        body  body  ResetPasswordReq  true  "Password change request"
 // @Success      200  {object}  utils.MessageRes
 // @Failure      400  {object}  utils.MessageRes
+// @Failure      401  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Failure      429  {object}  utils.MessageRes
 // @Router       /users/{user_id}/password-reset [put]
 func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	userIDstr := chi.URLParam(r, "user_id")
@@ -113,8 +163,17 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resetPasswordReq.ID = int64(userID)
+	resetPasswordReq.IP = utils.ClientIP(r)
 
 	res, err := h.service.ChangeUserPassword(r.Context(), &resetPasswordReq)
+	if errors.Is(err, ErrTooManyAttempts) {
+		utils.WriterErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, ErrPasswordMismatch) {
+		utils.WriterErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
 	if err != nil {
 		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -125,13 +184,14 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 // DeleteUser    godoc
 // @Summary      Delete User Details
-// @Description  Delete User Details by provided ID in url
+// @Description  Delete User Details by provided ID in url, needs a login or re-authentication within the last 5 minutes
 // @Tags         User
 // @Accept       json
 // @Produce      json
 // @Param        id  path  int  true  "User ID"
 // @Success      200  {object}  utils.MessageRes
 // @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
 // @Router       /users/{user_id}/delete [delete]
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userIDstr := chi.URLParam(r, "user_id")
@@ -149,3 +209,169 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteResponse(w, http.StatusOK, res)
 }
+
+// ListUsers     godoc
+// @Summary      List Users
+// @Description  List the users page by page, with search, filters and sorting, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        page          query  int     false  "Page number, starting at 1"  default(1)
+// @Param        page_size     query  int     false  "Users per page, at most 100"  default(20)
+// @Param        search        query  string  false  "Search in name, email and phone"
+// @Param        role          query  string  false  "Role of the users"
+// @Param        deleted       query  string  false  "Deleted status"  Enums(false, true, all)  default(false)
+// @Param        created_from  query  string  false  "Created at or after, RFC 3339 or YYYY-MM-DD"
+// @Param        created_to    query  string  false  "Created at or before, RFC 3339 or YYYY-MM-DD"
+// @Param        sort_by       query  string  false  "Sort field"  Enums(id, name, email, role, created_at, updated_at)  default(id)
+// @Param        sort_order    query  string  false  "Sort order"  Enums(asc, desc)  default(asc)
+// @Success      200  {object}  ListUsersRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /admin/users [get]
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	listUsersReq, err := parseListUsersReq(r.URL.Query())
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.Validate.Struct(listUsersReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.ListUsers(r.Context(), listUsersReq)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetUserDetails godoc
+// @Summary      Get Any User Details
+// @Description  Get the details of any user by provided ID in url, including the deleted and suspended users, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {object}  User
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /admin/users/{user_id} [get]
+func (h *Handler) GetUserDetails(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetUserDetails(r.Context(), userID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// RestoreUser   godoc
+// @Summary      Restore User
+// @Description  Restore the soft deleted user by provided ID in url, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  int  true  "User ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      403  {object}  utils.MessageRes
+// @Router       /admin/users/{user_id}/restore [post]
+func (h *Handler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	userIDstr := chi.URLParam(r, "user_id")
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.RestoreUser(r.Context(), userID)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// parseListUsersReq reads the user listing request from the query parameters, with the defaults for the missing ones.
+func parseListUsersReq(query url.Values) (*ListUsersReq, error) {
+	req := &ListUsersReq{
+		Page:      1,
+		PageSize:  20,
+		Search:    query.Get("search"),
+		Role:      query.Get("role"),
+		Deleted:   "false",
+		SortBy:    "id",
+		SortOrder: "asc",
+	}
+
+	var err error
+	if page := query.Get("page"); page != "" {
+		if req.Page, err = strconv.Atoi(page); err != nil {
+			return nil, fmt.Errorf("invalid page: %v", err)
+		}
+	}
+
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		if req.PageSize, err = strconv.Atoi(pageSize); err != nil {
+			return nil, fmt.Errorf("invalid page_size: %v", err)
+		}
+	}
+
+	if deleted := query.Get("deleted"); deleted != "" {
+		req.Deleted = deleted
+	}
+
+	if sortBy := query.Get("sort_by"); sortBy != "" {
+		req.SortBy = sortBy
+	}
+
+	if sortOrder := query.Get("sort_order"); sortOrder != "" {
+		req.SortOrder = sortOrder
+	}
+
+	if createdFrom := query.Get("created_from"); createdFrom != "" {
+		t, _, err := parseDateTime(createdFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_from: %v", err)
+		}
+		req.CreatedFrom = &t
+	}
+
+	if createdTo := query.Get("created_to"); createdTo != "" {
+		t, dateOnly, err := parseDateTime(createdTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_to: %v", err)
+		}
+		// A date includes the whole day
+		if dateOnly {
+			t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+		}
+		req.CreatedTo = &t
+	}
+
+	return req, nil
+}
+
+// parseDateTime parses an RFC 3339 time or a YYYY-MM-DD date, reporting whether it was a date.
+func parseDateTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
@@ -3,6 +3,8 @@ package user
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -20,13 +22,54 @@ type Repository interface {
 	// Update update user by user id and returns the updated user.
 	Update(ctx context.Context, user *User) (*User, error)
 
+	// ChangeRole update the user role by the user id
+	ChangeRole(ctx context.Context, userID int, role string) error
+
 	// ChangePassword update the user password by the user id
 	ChangePassword(ctx context.Context, userID int, password string) error
 
+	// ReplacePasswordHash update the user password by the user id only while it's still the old hash,
+	// returns false if it was changed meanwhile
+	ReplacePasswordHash(ctx context.Context, userID int, oldHash string, newHash string) (bool, error)
+
 	// Delete delete the given user based on user id
 	Delete(ctx context.Context, userID int) error
+
+	// MarkEmailVerified sets the email verified time of the user
+	MarkEmailVerified(ctx context.Context, userID int) error
+
+	// GetByPhone find and returns the user by user phone
+	GetByPhone(ctx context.Context, phone string) (*User, error)
+
+	// MarkPhoneVerified sets the phone of the user along with its verified time, as the phone was proven with a code
+	MarkPhoneVerified(ctx context.Context, userID int, phone string) error
+
+	// List returns a page of the users matching the filters along with the total count of matching users
+	List(ctx context.Context, filter *ListUsersReq) ([]*User, int, error)
+
+	// GetByIDWithDeleted find and returns the user by user id, including the deleted users
+	GetByIDWithDeleted(ctx context.Context, id int) (*User, error)
+
+	// SetSuspended suspends or unsuspends the user by the user id
+	SetSuspended(ctx context.Context, userID int, suspended bool) error
+
+	// Restore restores the soft deleted user by the user id
+	Restore(ctx context.Context, userID int) error
+}
+
+// sortColumns maps the sortable fields of the user listing to their columns
+var sortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"role":       "role",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
 }
 
+// likeEscaper escapes the LIKE wildcards so the search matches them literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type repository struct {
 	db *sql.DB
 }
@@ -42,7 +85,8 @@ func (r *repository) Create(ctx context.Context, user *User) (*User, error) {
 		createdAt time.Time
 		updatedAt time.Time
 	)
-	insertQuery := `INSERT INTO users(name, email, phone, role, password) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
+	// Users provisioned from an identity provider have no phone yet
+	insertQuery := `INSERT INTO users(name, email, phone, role, password) VALUES($1, $2, NULLIF($3, ''), $4, $5) RETURNING id, created_at, updated_at`
 
 	err := r.db.QueryRowContext(ctx, insertQuery,
 		user.Name,
@@ -66,7 +110,7 @@ func (r *repository) Create(ctx context.Context, user *User) (*User, error) {
 
 func (r *repository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	selectQueryByEmail := `SELECT id, name, email, phone, role, password, created_at, updated_at role FROM users WHERE email = $1 AND is_deleted = false`
+	selectQueryByEmail := `SELECT id, name, email, COALESCE(phone, ''), role, password, created_at, updated_at, email_verified_at, phone_verified_at, suspended_at FROM users WHERE email = $1 AND is_deleted = false`
 
 	err := r.db.QueryRowContext(ctx, selectQueryByEmail, email).Scan(
 		&user.ID,
@@ -77,6 +121,9 @@ func (r *repository) GetByEmail(ctx context.Context, email string) (*User, error
 		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.PhoneVerifiedAt,
+		&user.SuspendedAt,
 	)
 
 	if err != nil {
@@ -88,7 +135,7 @@ func (r *repository) GetByEmail(ctx context.Context, email string) (*User, error
 
 func (r *repository) GetByID(ctx context.Context, id int) (*User, error) {
 	var user User
-	selectQueryByID := `SELECT id, name, email, phone, role, password, created_at, updated_at FROM users WHERE id = $1 AND is_deleted = false`
+	selectQueryByID := `SELECT id, name, email, COALESCE(phone, ''), role, password, created_at, updated_at, email_verified_at, phone_verified_at, suspended_at FROM users WHERE id = $1 AND is_deleted = false`
 
 	err := r.db.QueryRowContext(ctx, selectQueryByID, id).Scan(
 		&user.ID,
@@ -99,6 +146,9 @@ func (r *repository) GetByID(ctx context.Context, id int) (*User, error) {
 		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.PhoneVerifiedAt,
+		&user.SuspendedAt,
 	)
 
 	if err != nil {
@@ -110,15 +160,15 @@ func (r *repository) GetByID(ctx context.Context, id int) (*User, error) {
 
 func (r *repository) Update(ctx context.Context, user *User) (*User, error) {
 	user.UpdatedAt = time.Now()
-	updateQuery := `UPDATE users SET name = $1, phone = $2, role = $3, updated_at = $4 WHERE id = $5`
+	// The phone is only changed by verifying the new one, see MarkPhoneVerified
+	updateQuery := `UPDATE users SET name = $1, updated_at = $2
+		WHERE id = $3 RETURNING COALESCE(phone, ''), email_verified_at, phone_verified_at`
 
-	_, err := r.db.ExecContext(ctx, updateQuery,
+	err := r.db.QueryRowContext(ctx, updateQuery,
 		user.Name,
-		user.Phone,
-		user.Role,
 		user.UpdatedAt,
 		user.ID,
-	)
+	).Scan(&user.Phone, &user.EmailVerifiedAt, &user.PhoneVerifiedAt)
 
 	if err != nil {
 		return nil, err
@@ -127,6 +177,14 @@ func (r *repository) Update(ctx context.Context, user *User) (*User, error) {
 	return user, nil
 }
 
+func (r *repository) ChangeRole(ctx context.Context, userID int, role string) error {
+	roleUpdateQuery := `UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, roleUpdateQuery, role, userID)
+
+	return err
+}
+
 func (r *repository) ChangePassword(ctx context.Context, userID int, password string) error {
 	passwordUpdateQuery := `UPDATE users SET password = $1 WHERE id = $2`
 
@@ -135,6 +193,22 @@ func (r *repository) ChangePassword(ctx context.Context, userID int, password st
 	return err
 }
 
+func (r *repository) ReplacePasswordHash(ctx context.Context, userID int, oldHash string, newHash string) (bool, error) {
+	passwordUpdateQuery := `UPDATE users SET password = $1 WHERE id = $2 AND password = $3`
+
+	result, err := r.db.ExecContext(ctx, passwordUpdateQuery, newHash, userID, oldHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
 func (r *repository) Delete(ctx context.Context, userID int) error {
 	deleteQuery := `UPDATE users SET is_deleted = true WHERE id = $1`
 
@@ -142,3 +216,203 @@ func (r *repository) Delete(ctx context.Context, userID int) error {
 
 	return err
 }
+
+func (r *repository) MarkEmailVerified(ctx context.Context, userID int) error {
+	verifyQuery := `UPDATE users SET email_verified_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, verifyQuery, userID)
+
+	return err
+}
+
+func (r *repository) GetByPhone(ctx context.Context, phone string) (*User, error) {
+	var user User
+	selectQueryByPhone := `SELECT id, name, email, COALESCE(phone, ''), role, password, created_at, updated_at, email_verified_at, phone_verified_at, suspended_at FROM users WHERE phone = $1 AND is_deleted = false`
+
+	err := r.db.QueryRowContext(ctx, selectQueryByPhone, phone).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Phone,
+		&user.Role,
+		&user.Password,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.PhoneVerifiedAt,
+		&user.SuspendedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *repository) MarkPhoneVerified(ctx context.Context, userID int, phone string) error {
+	verifyQuery := `UPDATE users SET phone = $2, phone_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND is_deleted = false`
+
+	result, err := r.db.ExecContext(ctx, verifyQuery, userID, phone)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, filter *ListUsersReq) ([]*User, int, error) {
+	var conditions []string
+	var args []any
+
+	// addArg adds the value to the query arguments and returns its placeholder
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch filter.Deleted {
+	case "true":
+		conditions = append(conditions, "is_deleted = true")
+	case "all":
+	default:
+		conditions = append(conditions, "is_deleted = false")
+	}
+
+	if filter.Search != "" {
+		search := addArg("%" + likeEscaper.Replace(filter.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE %[1]s OR email ILIKE %[1]s OR phone ILIKE %[1]s)", search))
+	}
+
+	if filter.Role != "" {
+		conditions = append(conditions, "role = "+addArg(filter.Role))
+	}
+
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+addArg(*filter.CreatedFrom))
+	}
+
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at <= "+addArg(*filter.CreatedTo))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users` + where
+
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortColumn, ok := sortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = "id"
+	}
+	sortOrder := "ASC"
+	if filter.SortOrder == "desc" {
+		sortOrder = "DESC"
+	}
+
+	// The id breaks the ties, so the pages don't overlap
+	selectQuery := `SELECT id, name, email, COALESCE(phone, ''), role, created_at, updated_at, email_verified_at, phone_verified_at, suspended_at, is_deleted FROM users` +
+		where +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, sortOrder, sortOrder) +
+		fmt.Sprintf(" LIMIT %s OFFSET %s", addArg(filter.PageSize), addArg((filter.Page-1)*filter.PageSize))
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		var user User
+		err := rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Email,
+			&user.Phone,
+			&user.Role,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.EmailVerifiedAt,
+			&user.PhoneVerifiedAt,
+			&user.SuspendedAt,
+			&user.IsDeleted,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, total, rows.Err()
+}
+
+func (r *repository) GetByIDWithDeleted(ctx context.Context, id int) (*User, error) {
+	var user User
+	selectQueryByID := `SELECT id, name, email, COALESCE(phone, ''), role, created_at, updated_at, email_verified_at, phone_verified_at, suspended_at, is_deleted FROM users WHERE id = $1`
+
+	err := r.db.QueryRowContext(ctx, selectQueryByID, id).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Phone,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.EmailVerifiedAt,
+		&user.PhoneVerifiedAt,
+		&user.SuspendedAt,
+		&user.IsDeleted,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *repository) SetSuspended(ctx context.Context, userID int, suspended bool) error {
+	// The suspension time of an already suspended user is kept
+	suspendQuery := `UPDATE users SET suspended_at = CASE WHEN $1 THEN COALESCE(suspended_at, CURRENT_TIMESTAMP) END WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, suspendQuery, suspended, userID)
+
+	return err
+}
+
+func (r *repository) Restore(ctx context.Context, userID int) error {
+	restoreQuery := `UPDATE users SET is_deleted = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND is_deleted = true`
+
+	result, err := r.db.ExecContext(ctx, restoreQuery, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
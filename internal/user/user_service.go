@@ -2,10 +2,12 @@ package user
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/internal/role"
 	"github.com/aslam-ep/go-e-commerce/utils"
 )
 
@@ -17,23 +19,54 @@ type Service interface {
 	// GetUserById Retrieves a user's details by their ID.
 	GetUserByID(c context.Context, id int) (*User, error)
 
+	// ChangeUserRole Changes the role of a user and returns the updated user's details.
+	ChangeUserRole(c context.Context, req *ChangeRoleReq) (*User, error)
+
 	// ChangeUserPassword Resets the user's password based on the provided request and returns a message indicating success or failure.
 	ChangeUserPassword(c context.Context, req *ResetPasswordReq) (*utils.MessageRes, error)
 
 	// DeleteUser Deletes a user by their ID and returns a message indicating success or failure.
 	DeleteUser(c context.Context, id int) (*utils.MessageRes, error)
+
+	// ListUsers Retrieves a page of the users matching the filters of the request.
+	ListUsers(c context.Context, req *ListUsersReq) (*ListUsersRes, error)
+
+	// GetUserDetails Retrieves any user's details by their ID, including the deleted and suspended users.
+	GetUserDetails(c context.Context, id int) (*User, error)
+
+	// RestoreUser Restores a soft deleted user by their ID and returns a message indicating success or failure.
+	RestoreUser(c context.Context, id int) (*utils.MessageRes, error)
 }
 
+// ErrPasswordMismatch is returned when the current password given for a sensitive operation is wrong.
+var ErrPasswordMismatch = errors.New("current password doesn't match")
+
+// ErrTooManyAttempts is returned while the password checks of the account or the IP address are throttled.
+var ErrTooManyAttempts = errors.New("too many failed attempts, try again later")
+
+// PasswordChecker checks the password of the user for a sensitive operation, throttled like the logins by the auth
+// domain which provides it. Returns ErrPasswordMismatch for a wrong password and ErrTooManyAttempts while throttled.
+type PasswordChecker func(ctx context.Context, u *User, password string, ip string) error
+
+// SessionRevoker ends every session of the user along with the access tokens issued from them, provided by the auth domain.
+type SessionRevoker func(ctx context.Context, userID int64) error
+
 type service struct {
-	userRepo Repository
-	timeout  time.Duration
+	userRepo       Repository
+	roleRepo       role.Repository
+	checkPassword  PasswordChecker
+	revokeSessions SessionRevoker
+	timeout        time.Duration
 }
 
 // NewService initialize and return the Service
-func NewService(ur Repository) Service {
+func NewService(ur Repository, rr role.Repository, checkPassword PasswordChecker, revokeSessions SessionRevoker) Service {
 	return &service{
-		userRepo: ur,
-		timeout:  time.Duration(config.AppConfig.DBTimeout) * time.Second,
+		userRepo:       ur,
+		roleRepo:       rr,
+		checkPassword:  checkPassword,
+		revokeSessions: revokeSessions,
+		timeout:        time.Duration(config.AppConfig.DBTimeout) * time.Second,
 	}
 }
 
@@ -54,6 +87,9 @@ func (s *service) GetUserByID(c context.Context, id int) (*User, error) {
 		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
 	}
 
 	return res, nil
@@ -64,7 +100,7 @@ func (s *service) UpdateUser(c context.Context, req *UpdateUserReq) (*User, erro
 	defer cancel()
 
 	// Check user exist before updating
-	_, err := s.userRepo.GetByID(ctx, int(req.ID))
+	existingUser, err := s.userRepo.GetByID(ctx, int(req.ID))
 	if err != nil {
 		return nil, err
 	}
@@ -72,8 +108,9 @@ func (s *service) UpdateUser(c context.Context, req *UpdateUserReq) (*User, erro
 	u := &User{
 		ID:    req.ID,
 		Name:  req.Name,
-		Phone: req.Phone,
-		Role:  req.Role,
+		Email: existingUser.Email,
+		Phone: existingUser.Phone,
+		Role:  existingUser.Role,
 	}
 
 	updatedUser, err := s.userRepo.Update(ctx, u)
@@ -89,6 +126,46 @@ func (s *service) UpdateUser(c context.Context, req *UpdateUserReq) (*User, erro
 		Role:      updatedUser.Role,
 		CreatedAt: updatedUser.CreatedAt,
 		UpdatedAt: updatedUser.UpdatedAt,
+
+		EmailVerifiedAt: updatedUser.EmailVerifiedAt,
+		PhoneVerifiedAt: updatedUser.PhoneVerifiedAt,
+	}
+
+	return res, nil
+}
+
+func (s *service) ChangeUserRole(c context.Context, req *ChangeRoleReq) (*User, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Check user exist before changing the role
+	user, err := s.userRepo.GetByID(ctx, int(req.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.roleRepo.GetByName(ctx, req.Role); err != nil {
+		return nil, errors.New("role doesn't exist")
+	}
+
+	err = s.userRepo.ChangeRole(ctx, int(user.ID), req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	// The issued tokens carry the permissions of the old role
+	if err := s.revokeSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	res := &User{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Phone:     user.Phone,
+		Role:      req.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
 	}
 
 	return res, nil
@@ -105,8 +182,8 @@ func (s *service) ChangeUserPassword(c context.Context, req *ResetPasswordReq) (
 	}
 
 	// Check current user db password and given password match
-	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
-		return nil, errors.New("current password doesn't match")
+	if err := s.checkPassword(ctx, user, req.CurrentPassword, req.IP); err != nil {
+		return nil, err
 	}
 
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
@@ -119,9 +196,14 @@ func (s *service) ChangeUserPassword(c context.Context, req *ResetPasswordReq) (
 		return nil, err
 	}
 
+	// Whoever knew the old password must not keep a session
+	if err := s.revokeSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
 	res := &utils.MessageRes{
 		Success: true,
-		Message: "Password updated,",
+		Message: "Password updated, login with the new password.",
 	}
 
 	return res, nil
@@ -149,3 +231,49 @@ func (s *service) DeleteUser(c context.Context, id int) (*utils.MessageRes, erro
 
 	return res, nil
 }
+
+func (s *service) ListUsers(c context.Context, req *ListUsersReq) (*ListUsersRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	users, total, err := s.userRepo.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListUsersRes{
+		Users:    users,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Total:    total,
+	}
+
+	return res, nil
+}
+
+func (s *service) GetUserDetails(c context.Context, id int) (*User, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.userRepo.GetByIDWithDeleted(ctx, id)
+}
+
+func (s *service) RestoreUser(c context.Context, id int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	err := s.userRepo.Restore(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("no deleted user found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "User restored.",
+	}
+
+	return res, nil
+}
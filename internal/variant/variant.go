@@ -0,0 +1,101 @@
+package variant
+
+import "time"
+
+// Option represents an option of a product, like Size or Color, along with its values.
+type Option struct {
+	ID        int64          `json:"id"`
+	ProductID int64          `json:"product_id"`
+	Name      string         `json:"name"`
+	Position  int            `json:"position"`
+	Values    []*OptionValue `json:"values"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// OptionValue represents a value of a product option, like XL or Red.
+type OptionValue struct {
+	ID        int64     `json:"id"`
+	OptionID  int64     `json:"option_id"`
+	Value     string    `json:"value"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Variant represents a purchasable combination of the option values of a product, like a red XL T-shirt.
+// Without a price the price of the product applies, the weight is in grams.
+// The stock is the available quantity of every location, on hand and not reserved, which the inventory keeps.
+type Variant struct {
+	ID             int64     `json:"id"`
+	ProductID      int64     `json:"product_id"`
+	VendorID       int64     `json:"vendor_id"`
+	SKU            string    `json:"sku"`
+	Price          *int64    `json:"price"`
+	Barcode        *string   `json:"barcode"`
+	Weight         int       `json:"weight"`
+	Stock          int       `json:"stock"`
+	OptionValueIDs []int64   `json:"option_value_ids"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreateOptionReq represents the request payload for adding an option with its values to a product of a vendor.
+type CreateOptionReq struct {
+	ProductID int64    `json:"product_id" validate:"required"`
+	VendorID  int64    `json:"vendor_id"`
+	Name      string   `json:"name" validate:"required,max=64"`
+	Position  int      `json:"position"`
+	Values    []string `json:"values" validate:"max=100,unique,dive,required,max=64"`
+}
+
+// AddOptionValueReq represents the request payload for adding a value to an option of a product of a vendor.
+type AddOptionValueReq struct {
+	ProductID int64  `json:"product_id" validate:"required"`
+	VendorID  int64  `json:"vendor_id"`
+	OptionID  int64  `json:"option_id" validate:"required"`
+	Value     string `json:"value" validate:"required,max=64"`
+	Position  int    `json:"position"`
+}
+
+// CreateVariantReq represents the request payload for adding a variant to a product of a vendor, with its initial stock
+// kept at the preferred location of the vendor.
+// The option values must have a single value of every option of the product.
+type CreateVariantReq struct {
+	ProductID      int64   `json:"product_id" validate:"required"`
+	VendorID       int64   `json:"vendor_id"`
+	SKU            string  `json:"sku" validate:"required,max=64"`
+	Price          *int64  `json:"price" validate:"omitempty,min=0"`
+	Barcode        *string `json:"barcode" validate:"omitempty,max=64"`
+	Weight         int     `json:"weight" validate:"min=0"`
+	Stock          int     `json:"stock" validate:"min=0"`
+	OptionValueIDs []int64 `json:"option_value_ids" validate:"unique"`
+}
+
+// UpdateVariantReq represents the request payload for updating a variant of a product of a vendor,
+// its option values can't be changed and its stock is adjusted through the inventory.
+type UpdateVariantReq struct {
+	ID        int64   `json:"id" validate:"required"`
+	ProductID int64   `json:"product_id" validate:"required"`
+	VendorID  int64   `json:"vendor_id"`
+	SKU       string  `json:"sku" validate:"required,max=64"`
+	Price     *int64  `json:"price" validate:"omitempty,min=0"`
+	Barcode   *string `json:"barcode" validate:"omitempty,max=64"`
+	Weight    int     `json:"weight" validate:"min=0"`
+}
+
+// GenerateVariantsReq represents the request payload for creating a variant of every combination of the option values
+// of a product of a vendor which doesn't have one yet. The SKUs join the prefix and the values, like TSHIRT-XL-RED,
+// without a prefix the slug of the product is used.
+type GenerateVariantsReq struct {
+	ProductID int64  `json:"product_id" validate:"required"`
+	VendorID  int64  `json:"vendor_id"`
+	SKUPrefix string `json:"sku_prefix" validate:"max=32"`
+	Price     *int64 `json:"price" validate:"omitempty,min=0"`
+	Weight    int    `json:"weight" validate:"min=0"`
+	Stock     int    `json:"stock" validate:"min=0"`
+}
+
+// ProductVariantsRes represents the options and the variants of a product.
+type ProductVariantsRes struct {
+	Options  []*Option  `json:"options"`
+	Variants []*Variant `json:"variants"`
+}
@@ -0,0 +1,517 @@
+package variant
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler struct to hold the variant service and provide handler functions
+type Handler struct {
+	service Service
+}
+
+// NewHandler initialize and return the variant Handler
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// GetProductVariants godoc
+// @Summary      Get Product Variants
+// @Description  Get the options and the variants of an active product by provided ID in url
+// @Tags         Product
+// @Accept       json
+// @Produce      json
+// @Param        product_id  path  int  true  "Product ID"
+// @Success      200  {object}  ProductVariantsRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /products/{product_id}/variants [get]
+func (h *Handler) GetProductVariants(w http.ResponseWriter, r *http.Request) {
+	productIDstr := chi.URLParam(r, "product_id")
+	productID, err := strconv.Atoi(productIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetProductVariants(r.Context(), productID)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// GetVendorProductVariants godoc
+// @Summary      Get Vendor Product Variants
+// @Description  Get the options and the variants of a product of the vendor in any status
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Success      200  {object}  ProductVariantsRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/variants [get]
+func (h *Handler) GetVendorProductVariants(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GetVendorProductVariants(r.Context(), vendorID, productID)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// CreateOption  godoc
+// @Summary      Create Product Option
+// @Description  Add an option with its values to a product of the vendor, before the product has variants
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        body  body  CreateOptionReq  true  "Option create request"
+// @Success      201  {object}  Option
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/options [post]
+func (h *Handler) CreateOption(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var createOptionReq CreateOptionReq
+	if err := utils.ReadFromRequest(r, &createOptionReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	createOptionReq.ProductID = int64(productID)
+	createOptionReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(createOptionReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CreateOption(r.Context(), &createOptionReq)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrOptionExists) || errors.Is(err, ErrValueExists) || errors.Is(err, ErrProductHasVariants) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// AddOptionValue godoc
+// @Summary      Add Product Option Value
+// @Description  Add a value to an option of a product of the vendor
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        option_id   path  int  true  "Option ID"
+// @Param        body  body  AddOptionValueReq  true  "Option value request"
+// @Success      201  {object}  OptionValue
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/options/{option_id}/values [post]
+func (h *Handler) AddOptionValue(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	optionIDstr := chi.URLParam(r, "option_id")
+	optionID, err := strconv.Atoi(optionIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var addOptionValueReq AddOptionValueReq
+	if err := utils.ReadFromRequest(r, &addOptionValueReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	addOptionValueReq.ProductID = int64(productID)
+	addOptionValueReq.VendorID = int64(vendorID)
+	addOptionValueReq.OptionID = int64(optionID)
+
+	if err := utils.Validate.Struct(addOptionValueReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.AddOptionValue(r.Context(), &addOptionValueReq)
+	if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrOptionNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrValueExists) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// DeleteOption  godoc
+// @Summary      Delete Product Option
+// @Description  Delete an option of a product of the vendor along with its values, unless a variant has one of them
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        option_id   path  int  true  "Option ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/options/{option_id} [delete]
+func (h *Handler) DeleteOption(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	optionIDstr := chi.URLParam(r, "option_id")
+	optionID, err := strconv.Atoi(optionIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.DeleteOption(r.Context(), vendorID, productID, optionID)
+	if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrOptionNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrOptionInUse) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// DeleteOptionValue godoc
+// @Summary      Delete Product Option Value
+// @Description  Delete a value of an option of a product of the vendor, unless a variant has it
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        option_id   path  int  true  "Option ID"
+// @Param        value_id    path  int  true  "Option value ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/options/{option_id}/values/{value_id} [delete]
+func (h *Handler) DeleteOptionValue(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	optionIDstr := chi.URLParam(r, "option_id")
+	optionID, err := strconv.Atoi(optionIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	valueIDstr := chi.URLParam(r, "value_id")
+	valueID, err := strconv.Atoi(valueIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.DeleteOptionValue(r.Context(), vendorID, productID, optionID, valueID)
+	if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrOptionNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrOptionInUse) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// CreateVariant godoc
+// @Summary      Create Product Variant
+// @Description  Add a variant with a single value of every option to a product of the vendor
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        body  body  CreateVariantReq  true  "Variant create request"
+// @Success      201  {object}  Variant
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/variants [post]
+func (h *Handler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var createVariantReq CreateVariantReq
+	if err := utils.ReadFromRequest(r, &createVariantReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	createVariantReq.ProductID = int64(productID)
+	createVariantReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(createVariantReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.CreateVariant(r.Context(), &createVariantReq)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrSKUTaken) || errors.Is(err, ErrVariantExists) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// GenerateVariants godoc
+// @Summary      Generate Product Variants
+// @Description  Create a variant of every combination of the option values a product of the vendor doesn't have yet
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        body  body  GenerateVariantsReq  true  "Variant generate request"
+// @Success      201  {array}   Variant
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/variants/generate [post]
+func (h *Handler) GenerateVariants(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var generateVariantsReq GenerateVariantsReq
+	if err := utils.ReadFromRequest(r, &generateVariantsReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	generateVariantsReq.ProductID = int64(productID)
+	generateVariantsReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(generateVariantsReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.GenerateVariants(r.Context(), &generateVariantsReq)
+	if errors.Is(err, ErrProductNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrSKUTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusCreated, res)
+}
+
+// UpdateVariant godoc
+// @Summary      Update Product Variant
+// @Description  Update a variant of a product of the vendor by provided ID in url and details in body
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        variant_id  path  int  true  "Variant ID"
+// @Param        body  body  UpdateVariantReq  true  "Variant update request"
+// @Success      200  {object}  Variant
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/variants/{variant_id} [put]
+func (h *Handler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	variantIDstr := chi.URLParam(r, "variant_id")
+	variantID, err := strconv.Atoi(variantIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var updateVariantReq UpdateVariantReq
+	if err := utils.ReadFromRequest(r, &updateVariantReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	updateVariantReq.ID = int64(variantID)
+	updateVariantReq.ProductID = int64(productID)
+	updateVariantReq.VendorID = int64(vendorID)
+
+	if err := utils.Validate.Struct(updateVariantReq); err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.UpdateVariant(r.Context(), &updateVariantReq)
+	if errors.Is(err, ErrVariantNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrSKUTaken) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// DeleteVariant godoc
+// @Summary      Delete Product Variant
+// @Description  Delete a variant of a product of the vendor
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        user_id     path  int  true  "Vendor user ID"
+// @Param        product_id  path  int  true  "Product ID"
+// @Param        variant_id  path  int  true  "Variant ID"
+// @Success      200  {object}  utils.MessageRes
+// @Failure      400  {object}  utils.MessageRes
+// @Failure      404  {object}  utils.MessageRes
+// @Failure      409  {object}  utils.MessageRes
+// @Router       /vendors/{user_id}/products/{product_id}/variants/{variant_id} [delete]
+func (h *Handler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	vendorID, productID, err := readVendorProductIDs(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	variantIDstr := chi.URLParam(r, "variant_id")
+	variantID, err := strconv.Atoi(variantIDstr)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.DeleteVariant(r.Context(), vendorID, productID, variantID)
+	if errors.Is(err, ErrVariantNotFound) {
+		utils.WriterErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrVariantReserved) || errors.Is(err, ErrVariantStocked) {
+		utils.WriterErrorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteResponse(w, http.StatusOK, res)
+}
+
+// readVendorProductIDs reads the vendor and product ids from the url.
+func readVendorProductIDs(r *http.Request) (int, int, error) {
+	vendorID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return vendorID, productID, nil
+}
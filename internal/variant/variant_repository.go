@@ -0,0 +1,445 @@
+package variant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+	"github.com/lib/pq"
+)
+
+// Repository interface for the variant repository
+type Repository interface {
+	// GetOptions returns the options of the product with their values, in the order of their positions.
+	GetOptions(ctx context.Context, productID int) ([]*Option, error)
+
+	// CreateOption stores a new option of the product along with its values and returns the created option,
+	// returns ErrOptionExists if the product has an option of the same name and ErrProductHasVariants if the
+	// product has variants.
+	CreateOption(ctx context.Context, option *Option) (*Option, error)
+
+	// AddOptionValue stores a new value of the option of the product and returns the created value, returns
+	// sql.ErrNoRows if the product has no such option and ErrValueExists if the option has the same value.
+	AddOptionValue(ctx context.Context, productID int, value *OptionValue) (*OptionValue, error)
+
+	// DeleteOption removes the option of the product with its values, returns sql.ErrNoRows if the product
+	// has no such option and ErrOptionInUse if a variant has one of its values.
+	DeleteOption(ctx context.Context, productID int, optionID int) error
+
+	// DeleteOptionValue removes the value of the option of the product, returns sql.ErrNoRows if the option
+	// has no such value and ErrOptionInUse if a variant has it.
+	DeleteOptionValue(ctx context.Context, productID int, optionID int, valueID int) error
+
+	// GetByID find and returns the variant by variant id
+	GetByID(ctx context.Context, id int) (*Variant, error)
+
+	// GetVariants returns the variants of the product along with their option values.
+	GetVariants(ctx context.Context, productID int) ([]*Variant, error)
+
+	// CreateVariants stores the variants in a single transaction and returns the created ones, skipping the variants
+	// whose combination of option values the product already has. Returns ErrSKUTaken if an SKU is in use by the vendor,
+	// ErrProductNotFound if the product isn't of the vendor and ErrInvalidCombination if the options of the product
+	// changed meanwhile.
+	CreateVariants(ctx context.Context, variants []*Variant) ([]*Variant, error)
+
+	// UpdateVariant updates the variant of the product of the vendor and returns the updated variant, returns
+	// sql.ErrNoRows if there's no such variant and ErrSKUTaken if the SKU is in use by the vendor.
+	UpdateVariant(ctx context.Context, variant *Variant) (*Variant, error)
+
+	// DeleteVariant removes the variant of the product of the vendor, returns sql.ErrNoRows if there's no such variant
+	// and ErrVariantStocked if it has stock records.
+	DeleteVariant(ctx context.Context, productID int, vendorID int, variantID int) error
+}
+
+// selectColumns are the columns read by scanVariant, in its order
+const selectColumns = `id, product_id, vendor_id, sku, price, barcode, weight,
+	COALESCE((SELECT SUM(on_hand - reserved) FROM inventory_levels WHERE variant_id = product_variants.id), 0), created_at, updated_at,
+	ARRAY(SELECT option_value_id FROM product_variant_values WHERE variant_id = product_variants.id ORDER BY option_value_id)`
+
+type repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize and return the Repository
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetOptions(ctx context.Context, productID int) ([]*Option, error) {
+	selectQuery := `SELECT o.id, o.product_id, o.name, o.position, o.created_at, v.id, v.value, v.position, v.created_at
+		FROM product_options o LEFT JOIN product_option_values v ON v.option_id = o.id
+		WHERE o.product_id = $1 ORDER BY o.position, o.id, v.position, v.id`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := []*Option{}
+	for rows.Next() {
+		var option Option
+		var valueID sql.NullInt64
+		var value sql.NullString
+		var valuePosition sql.NullInt64
+		var valueCreatedAt sql.NullTime
+
+		err := rows.Scan(
+			&option.ID,
+			&option.ProductID,
+			&option.Name,
+			&option.Position,
+			&option.CreatedAt,
+			&valueID,
+			&value,
+			&valuePosition,
+			&valueCreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// The values of an option come in a row, the option is added with the first one
+		if len(options) == 0 || options[len(options)-1].ID != option.ID {
+			option.Values = []*OptionValue{}
+			options = append(options, &option)
+		}
+		if valueID.Valid {
+			last := options[len(options)-1]
+			last.Values = append(last.Values, &OptionValue{
+				ID:        valueID.Int64,
+				OptionID:  option.ID,
+				Value:     value.String,
+				Position:  int(valuePosition.Int64),
+				CreatedAt: valueCreatedAt.Time,
+			})
+		}
+	}
+
+	return options, rows.Err()
+}
+
+func (r *repository) CreateOption(ctx context.Context, option *Option) (*Option, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The product is locked so no variant is created meanwhile without a value of the new option
+	err = lockProduct(ctx, tx, option.ProductID, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+
+	var hasVariants bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM product_variants WHERE product_id = $1)`, option.ProductID).
+		Scan(&hasVariants)
+	if err != nil {
+		return nil, err
+	}
+	if hasVariants {
+		return nil, ErrProductHasVariants
+	}
+
+	insertQuery := `INSERT INTO product_options(product_id, name, position) VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, insertQuery, option.ProductID, option.Name, option.Position).Scan(&option.ID, &option.CreatedAt)
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrOptionExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	insertValueQuery := `INSERT INTO product_option_values(option_id, value, position) VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	for _, value := range option.Values {
+		value.OptionID = option.ID
+		err = tx.QueryRowContext(ctx, insertValueQuery, value.OptionID, value.Value, value.Position).Scan(&value.ID, &value.CreatedAt)
+		if utils.IsUniqueViolation(err) {
+			return nil, ErrValueExists
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return option, tx.Commit()
+}
+
+func (r *repository) AddOptionValue(ctx context.Context, productID int, value *OptionValue) (*OptionValue, error) {
+	insertQuery := `INSERT INTO product_option_values(option_id, value, position)
+		SELECT id, $3, $4 FROM product_options WHERE id = $1 AND product_id = $2
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, insertQuery,
+		value.OptionID,
+		productID,
+		value.Value,
+		value.Position,
+	).Scan(&value.ID, &value.CreatedAt)
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrValueExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (r *repository) DeleteOption(ctx context.Context, productID int, optionID int) error {
+	deleteQuery := `DELETE FROM product_options WHERE id = $1 AND product_id = $2`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, optionID, productID)
+	if utils.IsForeignKeyViolation(err) {
+		return ErrOptionInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteOptionValue(ctx context.Context, productID int, optionID int, valueID int) error {
+	deleteQuery := `DELETE FROM product_option_values v USING product_options o
+		WHERE v.id = $1 AND v.option_id = $2 AND o.id = v.option_id AND o.product_id = $3`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, valueID, optionID, productID)
+	if utils.IsForeignKeyViolation(err) {
+		return ErrOptionInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*Variant, error) {
+	selectQueryByID := `SELECT ` + selectColumns + ` FROM product_variants WHERE id = $1`
+
+	return scanVariant(r.db.QueryRowContext(ctx, selectQueryByID, id))
+}
+
+func (r *repository) GetVariants(ctx context.Context, productID int) ([]*Variant, error) {
+	selectQuery := `SELECT ` + selectColumns + ` FROM product_variants WHERE product_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []*Variant{}
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants, rows.Err()
+}
+
+func (r *repository) CreateVariants(ctx context.Context, variants []*Variant) ([]*Variant, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A combination the product already has is skipped, the SKUs and the vendor are still checked by the constraints
+	insertQuery := `INSERT INTO product_variants(product_id, vendor_id, sku, price, barcode, weight, options_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (product_id, options_key) DO NOTHING
+		RETURNING id, created_at, updated_at`
+	insertValuesQuery := `INSERT INTO product_variant_values(variant_id, option_value_id) SELECT $1, unnest($2::INT[])`
+
+	// The options the combinations were checked against can't change until the variants are stored
+	optionCounts := make(map[int64]int)
+	for _, variant := range variants {
+		if _, ok := optionCounts[variant.ProductID]; ok {
+			continue
+		}
+
+		err = lockProduct(ctx, tx, variant.ProductID, "FOR SHARE")
+		if err != nil {
+			return nil, err
+		}
+
+		var count int
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM product_options WHERE product_id = $1`, variant.ProductID).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		optionCounts[variant.ProductID] = count
+	}
+
+	created := []*Variant{}
+	for _, variant := range variants {
+		if len(variant.OptionValueIDs) != optionCounts[variant.ProductID] {
+			return nil, ErrInvalidCombination
+		}
+
+		err = tx.QueryRowContext(ctx, insertQuery,
+			variant.ProductID,
+			variant.VendorID,
+			variant.SKU,
+			variant.Price,
+			variant.Barcode,
+			variant.Weight,
+			optionsKey(variant.OptionValueIDs),
+		).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if utils.IsUniqueViolation(err) {
+			return nil, ErrSKUTaken
+		}
+		if utils.IsForeignKeyViolation(err) {
+			return nil, ErrProductNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, insertValuesQuery, variant.ID, pq.Array(variant.OptionValueIDs))
+		if utils.IsForeignKeyViolation(err) {
+			return nil, ErrInvalidCombination
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		created = append(created, variant)
+	}
+
+	return created, tx.Commit()
+}
+
+func (r *repository) UpdateVariant(ctx context.Context, variant *Variant) (*Variant, error) {
+	updateQuery := `UPDATE product_variants SET sku = $1, price = $2, barcode = $3, weight = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND product_id = $6 AND vendor_id = $7 RETURNING ` + selectColumns
+
+	updatedVariant, err := scanVariant(r.db.QueryRowContext(ctx, updateQuery,
+		variant.SKU,
+		variant.Price,
+		variant.Barcode,
+		variant.Weight,
+		variant.ID,
+		variant.ProductID,
+		variant.VendorID,
+	))
+
+	if utils.IsUniqueViolation(err) {
+		return nil, ErrSKUTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedVariant, nil
+}
+
+func (r *repository) DeleteVariant(ctx context.Context, productID int, vendorID int, variantID int) error {
+	deleteQuery := `DELETE FROM product_variants WHERE id = $1 AND product_id = $2 AND vendor_id = $3`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, variantID, productID, vendorID)
+	// The stock records of the variant keep it from being deleted
+	if utils.IsForeignKeyViolation(err) {
+		return ErrVariantStocked
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// lockProduct locks the row of the product in the transaction with the given lock strength, returns
+// ErrProductNotFound if there's no such product.
+func lockProduct(ctx context.Context, tx *sql.Tx, productID int64, strength string) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM products WHERE id = $1 `+strength, productID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+
+	return err
+}
+
+// optionsKey lists the sorted ids of the option values, the same for every order of the same combination
+func optionsKey(optionValueIDs []int64) string {
+	ids := slices.Clone(optionValueIDs)
+	slices.Sort(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanVariant reads a variant selected with selectColumns
+func scanVariant(row scanner) (*Variant, error) {
+	var variant Variant
+
+	err := row.Scan(
+		&variant.ID,
+		&variant.ProductID,
+		&variant.VendorID,
+		&variant.SKU,
+		&variant.Price,
+		&variant.Barcode,
+		&variant.Weight,
+		&variant.Stock,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+		pq.Array(&variant.OptionValueIDs),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
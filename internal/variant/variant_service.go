@@ -0,0 +1,500 @@
+package variant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+	"github.com/aslam-ep/go-e-commerce/internal/location"
+	"github.com/aslam-ep/go-e-commerce/internal/product"
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// maxGeneratedVariants is the most variants a product can have generated at once
+const maxGeneratedVariants = 500
+
+// Service interface for the variant service
+type Service interface {
+	// GetProductVariants Retrieves the options and the variants of an active product.
+	GetProductVariants(c context.Context, productID int) (*ProductVariantsRes, error)
+
+	// GetVendorProductVariants Retrieves the options and the variants of a product of the vendor, in any status.
+	GetVendorProductVariants(c context.Context, vendorID int, productID int) (*ProductVariantsRes, error)
+
+	// CreateOption Adds an option with its values to a product of the vendor and returns it.
+	CreateOption(c context.Context, req *CreateOptionReq) (*Option, error)
+
+	// AddOptionValue Adds a value to an option of a product of the vendor and returns it.
+	AddOptionValue(c context.Context, req *AddOptionValueReq) (*OptionValue, error)
+
+	// DeleteOption Deletes an option of a product of the vendor and returns a message indicating success or failure.
+	DeleteOption(c context.Context, vendorID int, productID int, optionID int) (*utils.MessageRes, error)
+
+	// DeleteOptionValue Deletes a value of an option of a product of the vendor and returns a message indicating success or failure.
+	DeleteOptionValue(c context.Context, vendorID int, productID int, optionID int, valueID int) (*utils.MessageRes, error)
+
+	// CreateVariant Adds a variant to a product of the vendor and returns it.
+	CreateVariant(c context.Context, req *CreateVariantReq) (*Variant, error)
+
+	// GenerateVariants Creates the variants of the combinations a product of the vendor doesn't have yet and returns them.
+	GenerateVariants(c context.Context, req *GenerateVariantsReq) ([]*Variant, error)
+
+	// UpdateVariant Updates a variant of a product of the vendor and returns it.
+	UpdateVariant(c context.Context, req *UpdateVariantReq) (*Variant, error)
+
+	// DeleteVariant Deletes a variant of a product of the vendor and returns a message indicating success or failure.
+	DeleteVariant(c context.Context, vendorID int, productID int, variantID int) (*utils.MessageRes, error)
+}
+
+// ErrProductNotFound is returned when the product doesn't exist, isn't active or belongs to another vendor.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrOptionNotFound is returned when the product has no such option or option value.
+var ErrOptionNotFound = errors.New("option not found")
+
+// ErrOptionExists is returned when the product already has an option of the same name.
+var ErrOptionExists = errors.New("product already has an option of the same name")
+
+// ErrValueExists is returned when the option already has the same value.
+var ErrValueExists = errors.New("option already has the same value")
+
+// ErrOptionInUse is returned when deleting an option or an option value which a variant has.
+var ErrOptionInUse = errors.New("option is used by variants, delete them first")
+
+// ErrProductHasVariants is returned when adding an option to a product which already has variants,
+// as they would be left without a value of the new option.
+var ErrProductHasVariants = errors.New("options can't be added to a product with variants")
+
+// ErrVariantNotFound is returned when the product has no such variant.
+var ErrVariantNotFound = errors.New("variant not found")
+
+// ErrVariantExists is returned when the product already has a variant of the same combination of option values.
+var ErrVariantExists = errors.New("product already has a variant of the same option values")
+
+// ErrVariantReserved is returned when deleting a variant whose stock is held by checkouts in progress.
+var ErrVariantReserved = errors.New("variant stock is reserved by checkouts in progress")
+
+// ErrVariantStocked is returned when a variant is stocked again while being deleted.
+var ErrVariantStocked = errors.New("variant was stocked meanwhile, try again")
+
+// ErrSKUTaken is returned when the SKU belongs to another variant of the vendor.
+var ErrSKUTaken = errors.New("sku already in use")
+
+// ErrNoLocation is returned when a variant has initial stock but the vendor has no active location to keep it.
+var ErrNoLocation = errors.New("add an active location before stocking variants")
+
+// ErrInvalidCombination is returned when the option values of a variant aren't a single value of every option of the product.
+var ErrInvalidCombination = errors.New("variant must have a single value of every option of the product")
+
+// ErrNoOptionValues is returned when generating the variants of a product with an option without values.
+var ErrNoOptionValues = errors.New("every option needs a value to generate the variants")
+
+// ErrTooManyVariants is returned when generating more variants than maxGeneratedVariants.
+var ErrTooManyVariants = errors.New("too many combinations of option values")
+
+// ErrSKUTooLong is returned when a generated SKU is longer than 64 characters.
+var ErrSKUTooLong = errors.New("generated sku longer than 64 characters, use a shorter prefix")
+
+// StockBooker books the stock a new variant of the vendor is created with at a location of the vendor. The stock
+// changes only through the ledger of the inventory domain, which provides it.
+type StockBooker func(ctx context.Context, vendorID int64, variantID int64, locationID int64, quantity int) error
+
+// StockRemover writes off the stock of a variant of the vendor before it's deleted, provided by the inventory domain too.
+type StockRemover func(ctx context.Context, vendorID int64, variantID int64) error
+
+type service struct {
+	variantRepo  Repository
+	productRepo  product.Repository
+	locationRepo location.Repository
+	bookStock    StockBooker
+	removeStock  StockRemover
+	timeout      time.Duration
+}
+
+// NewService initialize and return the Service
+func NewService(vr Repository, pr product.Repository, lr location.Repository, bookStock StockBooker, removeStock StockRemover) Service {
+	return &service{
+		variantRepo:  vr,
+		productRepo:  pr,
+		locationRepo: lr,
+		bookStock:    bookStock,
+		removeStock:  removeStock,
+		timeout:      time.Duration(config.AppConfig.DBTimeout) * time.Second,
+	}
+}
+
+func (s *service) GetProductVariants(c context.Context, productID int) (*ProductVariantsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && p.Status != product.StatusActive) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getProductVariants(ctx, productID)
+}
+
+func (s *service) GetVendorProductVariants(c context.Context, vendorID int, productID int) (*ProductVariantsRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getVendorProduct(ctx, vendorID, productID); err != nil {
+		return nil, err
+	}
+
+	return s.getProductVariants(ctx, productID)
+}
+
+func (s *service) CreateOption(c context.Context, req *CreateOptionReq) (*Option, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getVendorProduct(ctx, int(req.VendorID), int(req.ProductID)); err != nil {
+		return nil, err
+	}
+
+	option := &Option{
+		ProductID: req.ProductID,
+		Name:      req.Name,
+		Position:  req.Position,
+		Values:    make([]*OptionValue, len(req.Values)),
+	}
+	// The values keep the order of the request
+	for i, value := range req.Values {
+		option.Values[i] = &OptionValue{Value: value, Position: i}
+	}
+
+	return s.variantRepo.CreateOption(ctx, option)
+}
+
+func (s *service) AddOptionValue(c context.Context, req *AddOptionValueReq) (*OptionValue, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getVendorProduct(ctx, int(req.VendorID), int(req.ProductID)); err != nil {
+		return nil, err
+	}
+
+	value := &OptionValue{
+		OptionID: req.OptionID,
+		Value:    req.Value,
+		Position: req.Position,
+	}
+
+	createdValue, err := s.variantRepo.AddOptionValue(ctx, int(req.ProductID), value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return createdValue, nil
+}
+
+func (s *service) DeleteOption(c context.Context, vendorID int, productID int, optionID int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getVendorProduct(ctx, vendorID, productID); err != nil {
+		return nil, err
+	}
+
+	err := s.variantRepo.DeleteOption(ctx, productID, optionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Option deleted.",
+	}
+
+	return res, nil
+}
+
+func (s *service) DeleteOptionValue(c context.Context, vendorID int, productID int, optionID int, valueID int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getVendorProduct(ctx, vendorID, productID); err != nil {
+		return nil, err
+	}
+
+	err := s.variantRepo.DeleteOptionValue(ctx, productID, optionID, valueID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Option value deleted.",
+	}
+
+	return res, nil
+}
+
+func (s *service) CreateVariant(c context.Context, req *CreateVariantReq) (*Variant, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getVendorProduct(ctx, int(req.VendorID), int(req.ProductID)); err != nil {
+		return nil, err
+	}
+
+	options, err := s.variantRepo.GetOptions(ctx, int(req.ProductID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Every option needs exactly one of its values
+	optionOf := make(map[int64]int64)
+	for _, option := range options {
+		for _, value := range option.Values {
+			optionOf[value.ID] = option.ID
+		}
+	}
+	seen := make(map[int64]bool)
+	for _, valueID := range req.OptionValueIDs {
+		optionID, ok := optionOf[valueID]
+		if !ok || seen[optionID] {
+			return nil, ErrInvalidCombination
+		}
+		seen[optionID] = true
+	}
+	if len(seen) != len(options) {
+		return nil, ErrInvalidCombination
+	}
+
+	variant := &Variant{
+		ProductID:      req.ProductID,
+		VendorID:       req.VendorID,
+		SKU:            req.SKU,
+		Price:          req.Price,
+		Barcode:        req.Barcode,
+		Weight:         req.Weight,
+		Stock:          req.Stock,
+		OptionValueIDs: req.OptionValueIDs,
+	}
+	if variant.OptionValueIDs == nil {
+		variant.OptionValueIDs = []int64{}
+	}
+
+	created, err := s.createVariants(ctx, req.VendorID, []*Variant{variant})
+	if err != nil {
+		return nil, err
+	}
+	if len(created) == 0 {
+		return nil, ErrVariantExists
+	}
+
+	return created[0], nil
+}
+
+func (s *service) GenerateVariants(c context.Context, req *GenerateVariantsReq) ([]*Variant, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	p, err := s.getVendorProduct(ctx, int(req.VendorID), int(req.ProductID))
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.variantRepo.GetOptions(ctx, int(req.ProductID))
+	if err != nil {
+		return nil, err
+	}
+
+	total := 1
+	for _, option := range options {
+		if len(option.Values) == 0 {
+			return nil, ErrNoOptionValues
+		}
+		total *= len(option.Values)
+		if total > maxGeneratedVariants {
+			return nil, ErrTooManyVariants
+		}
+	}
+
+	prefix := req.SKUPrefix
+	if prefix == "" {
+		prefix = p.Slug
+		if len(prefix) > 32 {
+			prefix = strings.TrimRight(prefix[:32], "-")
+		}
+	}
+	prefix = strings.ToUpper(prefix)
+
+	// Every combination starts from the prefix and gets one value of each option, in the order of the options
+	combinations := []*Variant{{SKU: prefix, OptionValueIDs: []int64{}}}
+	for _, option := range options {
+		next := make([]*Variant, 0, len(combinations)*len(option.Values))
+		for _, combination := range combinations {
+			for _, value := range option.Values {
+				next = append(next, &Variant{
+					SKU:            combination.SKU + "-" + strings.ToUpper(utils.Slugify(value.Value, "v"+strconv.FormatInt(value.ID, 10))),
+					OptionValueIDs: append(slices.Clone(combination.OptionValueIDs), value.ID),
+				})
+			}
+		}
+		combinations = next
+	}
+
+	for _, variant := range combinations {
+		if len(variant.SKU) > 64 {
+			return nil, ErrSKUTooLong
+		}
+		variant.ProductID = req.ProductID
+		variant.VendorID = req.VendorID
+		variant.Price = req.Price
+		variant.Weight = req.Weight
+		variant.Stock = req.Stock
+	}
+
+	return s.createVariants(ctx, req.VendorID, combinations)
+}
+
+func (s *service) UpdateVariant(c context.Context, req *UpdateVariantReq) (*Variant, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	variant := &Variant{
+		ID:        req.ID,
+		ProductID: req.ProductID,
+		VendorID:  req.VendorID,
+		SKU:       req.SKU,
+		Price:     req.Price,
+		Barcode:   req.Barcode,
+		Weight:    req.Weight,
+	}
+
+	updatedVariant, err := s.variantRepo.UpdateVariant(ctx, variant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedVariant, nil
+}
+
+func (s *service) DeleteVariant(c context.Context, vendorID int, productID int, variantID int) (*utils.MessageRes, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	v, err := s.variantRepo.GetByID(ctx, variantID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (v.ProductID != int64(productID) || v.VendorID != int64(vendorID))) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The stock is written off in the ledger first, its records keep the variant from being deleted
+	if err := s.removeStock(ctx, v.VendorID, v.ID); err != nil {
+		return nil, err
+	}
+
+	err = s.variantRepo.DeleteVariant(ctx, productID, vendorID, variantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &utils.MessageRes{
+		Success: true,
+		Message: "Variant deleted.",
+	}
+
+	return res, nil
+}
+
+// createVariants stores the variants, then books their initial stock at the preferred active location of the vendor.
+// A variant whose stock fails to be booked is left without stock, which the vendor adds with an adjustment.
+func (s *service) createVariants(ctx context.Context, vendorID int64, variants []*Variant) ([]*Variant, error) {
+	var locationID int64
+	if slices.ContainsFunc(variants, func(v *Variant) bool { return v.Stock > 0 }) {
+		locations, err := s.locationRepo.ListByVendor(ctx, int(vendorID))
+		if err != nil {
+			return nil, err
+		}
+
+		// Ordered by priority, the first active one is preferred
+		i := slices.IndexFunc(locations, func(l *location.Location) bool { return l.Active })
+		if i < 0 {
+			return nil, ErrNoLocation
+		}
+		locationID = locations[i].ID
+	}
+
+	created, err := s.variantRepo.CreateVariants(ctx, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range created {
+		if variant.Stock == 0 {
+			continue
+		}
+
+		err = s.bookStock(ctx, vendorID, variant.ID, locationID, variant.Stock)
+		if err != nil {
+			log.Printf("Failed to book the initial stock of variant %d: %v", variant.ID, err)
+			variant.Stock = 0
+		}
+	}
+
+	return created, nil
+}
+
+// getVendorProduct returns the product of the vendor, in any status.
+func (s *service) getVendorProduct(ctx context.Context, vendorID int, productID int) (*product.Product, error) {
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && p.VendorID != int64(vendorID)) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// getProductVariants returns the options and the variants of the product.
+func (s *service) getProductVariants(ctx context.Context, productID int) (*ProductVariantsRes, error) {
+	options, err := s.variantRepo.GetOptions(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := s.variantRepo.GetVariants(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ProductVariantsRes{
+		Options:  options,
+		Variants: variants,
+	}
+
+	return res, nil
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// APIKeyHeader is the header carrying a personal API key, accepted instead of a Bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// RejectAPIKeys middleware for restricting the routes to the users who logged in, like managing the credentials,
+// so a leaked API key can't be used to take over the account.
+func RejectAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Retrieving claims from context by auth middleware
+		claims, ok := utils.GetClaims(r.Context())
+		if !ok || claims.Type == utils.APIKeyType {
+			utils.WriterErrorResponse(w, http.StatusForbidden, "Not allowed with an API key")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -5,20 +5,49 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/aslam-ep/go-e-commerce/config"
 	"github.com/aslam-ep/go-e-commerce/utils"
 )
 
-type contextKey string
+// APIKeyAuthenticator resolves an API key into the claims of its user.
+type APIKeyAuthenticator func(ctx context.Context, key string) (*utils.Claims, error)
 
-// UserContextKey const to hold the custom type for user context value.
-const UserContextKey = contextKey("user")
+// ImpersonationAuditor records a request made while an admin impersonates a user before it's served, and returns the
+// function recording its response status.
+type ImpersonationAuditor func(r *http.Request) (func(status int), error)
 
-// AuthMiddleware middleware for checking the given token is valid one.
-func AuthMiddleware(next http.Handler) http.Handler {
+// Auth holds the dependencies of the auth middleware, which come from the domains set up along with the routes.
+type Auth struct {
+	authenticateAPIKey APIKeyAuthenticator
+	auditImpersonation ImpersonationAuditor
+}
+
+// NewAuth initialize and return the auth middleware
+func NewAuth(authenticator APIKeyAuthenticator, auditor ImpersonationAuditor) *Auth {
+	return &Auth{
+		authenticateAPIKey: authenticator,
+		auditImpersonation: auditor,
+	}
+}
+
+// Authenticate middleware for checking the given token or API key is valid one.
+func (a *Auth) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get the token from the authorization header
 		authHeader := r.Header.Get("Authorization")
+
+		// API keys are looked up on every request, so they carry the same identity as a token
+		if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" && authHeader == "" {
+			claims, err := a.authenticateAPIKey(r.Context(), apiKey)
+			if err != nil {
+				utils.WriterErrorResponse(w, http.StatusUnauthorized, "Invalid API key")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), utils.UserContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		if authHeader == "" {
 			utils.WriterErrorResponse(w, http.StatusUnauthorized, "Authorization header is missing")
 			return
@@ -32,14 +61,27 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Validate token
-		claims, err := utils.ValidateToken(tokenStr, config.AppConfig.JWTSecret)
-		if err != nil {
+		claims, err := utils.ValidateToken(tokenStr)
+		if err != nil || claims.Type != utils.AccessTokenType {
 			utils.WriterErrorResponse(w, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 
-		// Store the user id in context
-		ctx := context.WithValue(r.Context(), UserContextKey, claims["user_id"])
+		// Reject tokens whose session has been logged out
+		if claims.SessionID != "" && utils.RevokedSessions.Contains(claims.SessionID) {
+			utils.WriterErrorResponse(w, http.StatusUnauthorized, "Token revoked")
+			return
+		}
+
+		// Store the claims in context
+		ctx := context.WithValue(r.Context(), utils.UserContextKey, claims)
+
+		if claims.Actor != nil {
+			ctx = context.WithValue(ctx, utils.ActorContextKey, claims.Actor)
+			a.serveImpersonated(next, w, r.WithContext(ctx))
+			return
+		}
+
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// RejectImpersonation middleware for restricting the routes to the users themselves, like changing the password,
+// deleting the account or paying, so the admins acting as them can't.
+func RejectImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := utils.GetActor(r.Context()); ok {
+			utils.WriterErrorResponse(w, http.StatusForbidden, "Not allowed while impersonating")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveImpersonated audits a request made with an impersonation token and serves it, the requests which can't be
+// audited aren't served.
+func (a *Auth) serveImpersonated(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	recordStatus, err := a.auditImpersonation(r)
+	if err != nil {
+		utils.WriterErrorResponse(w, http.StatusServiceUnavailable, "Impersonated requests can't be audited right now")
+		return
+	}
+
+	ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	next.ServeHTTP(ww, r)
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	recordStatus(status)
+}
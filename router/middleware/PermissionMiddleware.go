@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// RequirePermission middleware for restricting the routes to the users whose role grants the permission.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Retrieving claims from context by auth middleware
+			claims, ok := utils.GetClaims(r.Context())
+			if !ok || !slices.Contains(claims.Permissions, permission) {
+				utils.WriterErrorResponse(w, http.StatusForbidden, "User not allowed")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -10,8 +10,8 @@ import (
 // ProfileMiddleware middleware for checking the current resource to the logged in user
 func ProfileMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Retrieving user id from context by auth middleware
-		userID, ok := r.Context().Value(UserContextKey).(string)
+		// Retrieving claims from context by auth middleware
+		claims, ok := utils.GetClaims(r.Context())
 		if !ok {
 			utils.WriterErrorResponse(w, http.StatusUnauthorized, "User not authorized")
 			return
@@ -19,7 +19,7 @@ func ProfileMiddleware(next http.Handler) http.Handler {
 
 		// Retrieving the user id from url parameter
 		paramID := chi.URLParam(r, "user_id")
-		if userID != paramID {
+		if claims.Subject != paramID {
 			utils.WriterErrorResponse(w, http.StatusUnauthorized, "User not authorized")
 			return
 		}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aslam-ep/go-e-commerce/utils"
+)
+
+// RequireRecentAuth middleware for restricting the sensitive routes, like deleting the account, to the users who
+// proved their credentials within maxAge, through a login or a re-authentication.
+func RequireRecentAuth(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Retrieving claims from context by auth middleware
+			claims, ok := utils.GetClaims(r.Context())
+			if !ok || !claims.AuthenticatedWithin(maxAge) {
+				utils.WriterErrorResponse(w, http.StatusForbidden, "Recent authentication required, reauthenticate first")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -13,48 +13,114 @@ import (
 	"github.com/aslam-ep/go-e-commerce/config"
 	// Import for swagger docs for swagger handler
 	_ "github.com/aslam-ep/go-e-commerce/docs/swagger"
+	"github.com/aslam-ep/go-e-commerce/internal/apikey"
 	"github.com/aslam-ep/go-e-commerce/internal/auth"
+	"github.com/aslam-ep/go-e-commerce/internal/category"
+	"github.com/aslam-ep/go-e-commerce/internal/inventory"
+	"github.com/aslam-ep/go-e-commerce/internal/location"
+	"github.com/aslam-ep/go-e-commerce/internal/mailer"
+	"github.com/aslam-ep/go-e-commerce/internal/product"
+	"github.com/aslam-ep/go-e-commerce/internal/role"
+	"github.com/aslam-ep/go-e-commerce/internal/sms"
 	"github.com/aslam-ep/go-e-commerce/internal/user"
+	"github.com/aslam-ep/go-e-commerce/internal/variant"
 	"github.com/aslam-ep/go-e-commerce/router/middleware"
 	"github.com/aslam-ep/go-e-commerce/utils"
 )
 
 // Router struct to hold router, database and handlers
 type Router struct {
-	Mux         chi.Router
-	apiVersion  string
-	authHandler *auth.Handler
-	userHandler *user.Handler
+	Mux              chi.Router
+	apiVersion       string
+	authMiddleware   *middleware.Auth
+	authHandler      *auth.Handler
+	userHandler      *user.Handler
+	roleHandler      *role.Handler
+	apiKeyHandler    *apikey.Handler
+	productHandler   *product.Handler
+	categoryHandler  *category.Handler
+	variantHandler   *variant.Handler
+	locationHandler  *location.Handler
+	inventoryHandler *inventory.Handler
 }
 
 // NewRouter initialize and setup chi router along with the server
-func NewRouter(db *sql.DB) *Router {
+func NewRouter(db *sql.DB, allocator inventory.Allocator) *Router {
 	// Initialize router
 	r := chi.NewRouter()
 	r.Use(chiMiddleware.Logger)
 	r.Use(httprate.LimitByIP(config.AppConfig.APIRateLimit, time.Minute))
 	r.Use(middleware.CORS)
 
-	// Initialize user domain
-	userRepo := user.NewRepository(db)
-	userServ := user.NewService(userRepo)
-	userHandler := user.NewHandler(userServ)
+	// Initialize role domain
+	roleRepo := role.NewRepository(db)
+	roleServ := role.NewService(roleRepo)
+	roleHandler := role.NewHandler(roleServ)
 
 	// Initialize auth domain
+	userRepo := user.NewRepository(db)
 	authRepo := auth.NewRepository(db)
-	authServ := auth.NewService(userRepo, authRepo)
+	authServ := auth.NewService(userRepo, authRepo, roleRepo, mailer.New(), sms.New())
 	authHandler := auth.NewHandler(authServ)
 
+	// Initialize user domain, the passwords and the sessions are checked and revoked by the auth domain
+	userServ := user.NewService(userRepo, roleRepo, authServ.CheckPassword, authServ.RevokeUserSessions)
+	userHandler := user.NewHandler(userServ)
+
+	// Initialize API key domain
+	apiKeyRepo := apikey.NewRepository(db)
+	apiKeyServ := apikey.NewService(apiKeyRepo, userRepo, roleRepo)
+	apiKeyHandler := apikey.NewHandler(apiKeyServ)
+
+	// Initialize auth middleware, API keys and impersonation tokens are resolved and audited by the domains above
+	authMiddleware := middleware.NewAuth(apiKeyServ.Authenticate, authHandler.AuditImpersonation)
+
+	// Initialize product domain
+	productRepo := product.NewRepository(db)
+	productServ := product.NewService(productRepo)
+	productHandler := product.NewHandler(productServ)
+
+	// Initialize location domain
+	locationRepo := location.NewRepository(db)
+	locationServ := location.NewService(locationRepo)
+	locationHandler := location.NewHandler(locationServ)
+
+	// Initialize inventory domain
+	variantRepo := variant.NewRepository(db)
+	inventoryRepo := inventory.NewRepository(db)
+	inventoryServ := inventory.NewService(inventoryRepo, variantRepo, locationRepo, allocator)
+	inventoryHandler := inventory.NewHandler(inventoryServ)
+
+	// Initialize variant domain, the stock of the variants is booked and written off by the inventory domain
+	variantServ := variant.NewService(variantRepo, productRepo, locationRepo, inventoryServ.AddInitialStock, inventoryServ.RemoveVariantStock)
+	variantHandler := variant.NewHandler(variantServ)
+
+	// Initialize category domain
+	categoryRepo := category.NewRepository(db)
+	categoryServ := category.NewService(categoryRepo)
+	categoryHandler := category.NewHandler(categoryServ)
+
 	return &Router{
-		Mux:         r,
-		apiVersion:  "/api/v1",
-		authHandler: authHandler,
-		userHandler: userHandler,
+		Mux:              r,
+		apiVersion:       "/api/v1",
+		authMiddleware:   authMiddleware,
+		authHandler:      authHandler,
+		userHandler:      userHandler,
+		roleHandler:      roleHandler,
+		apiKeyHandler:    apiKeyHandler,
+		productHandler:   productHandler,
+		categoryHandler:  categoryHandler,
+		variantHandler:   variantHandler,
+		locationHandler:  locationHandler,
+		inventoryHandler: inventoryHandler,
 	}
 }
 
 // SetupRoutes Initialize end points
 func (router Router) SetupRoutes() {
+	// Public keys for verifying the access tokens, served outside the versioned api
+	router.Mux.Get("/.well-known/jwks.json", router.authHandler.JWKS)
+
 	router.Mux.Route(router.apiVersion, func(r chi.Router) {
 		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
 			utils.WriteResponse(w, http.StatusAccepted, &utils.MessageRes{
@@ -70,16 +136,155 @@ func (router Router) SetupRoutes() {
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/register", router.authHandler.Register)
 			r.Post("/login", router.authHandler.Login)
+			r.Post("/login/mfa", router.authHandler.LoginMFA)
 			r.Post("/refresh-token", router.authHandler.RefreshToken)
+			r.Post("/logout", router.authHandler.Logout)
+			r.Post("/verify-email", router.authHandler.VerifyEmail)
+			r.Post("/resend-verification", router.authHandler.ResendVerification)
+			r.Post("/forgot-password", router.authHandler.ForgotPassword)
+			r.Post("/reset-password", router.authHandler.ResetPassword)
+			r.Post("/change-email/confirm", router.authHandler.ConfirmEmailChange)
+			r.Post("/change-email/cancel", router.authHandler.CancelEmailChange)
+			r.Post("/phone/otp", router.authHandler.PhoneOTP)
+			r.Post("/phone/login", router.authHandler.PhoneLogin)
+			r.Post("/magic-link", router.authHandler.MagicLink)
+			r.Post("/magic-link/consume", router.authHandler.ConsumeMagicLink)
+			r.Get("/oidc/{provider}/login", router.authHandler.OIDCLogin)
+			r.Get("/oidc/{provider}/callback", router.authHandler.OIDCCallback)
+			r.With(router.authMiddleware.Authenticate, middleware.RejectAPIKeys, middleware.RejectImpersonation).
+				Post("/reauthenticate", router.authHandler.Reauthenticate)
 		})
 
 		// User Router group
-		r.With(middleware.AuthMiddleware, middleware.ProfileMiddleware).
-			Route("users/{user_id}", func(r chi.Router) {
-				r.Get("/", router.userHandler.GetUser)
-				r.Put("/update", router.userHandler.UpdateUser)
-				r.Put("/reset-password", router.userHandler.ChangePassword)
-				r.Delete("/delete", router.userHandler.DeleteUser)
+		r.With(router.authMiddleware.Authenticate, middleware.ProfileMiddleware).
+			Route("/users/{user_id}", func(r chi.Router) {
+				// Profile, API keys reach it with the profile scopes only
+				r.With(middleware.RequirePermission("profile:read")).Get("/", router.userHandler.GetUser)
+				r.With(middleware.RequirePermission("profile:write"), middleware.RejectImpersonation).Put("/update", router.userHandler.UpdateUser)
+
+				// Account management, only for the users who logged in so a leaked API key can't take over the account
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.RejectAPIKeys)
+
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Put("/reset-password", router.userHandler.ChangePassword)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Delete("/delete", router.userHandler.DeleteUser)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Post("/change-email", router.authHandler.ChangeEmail)
+					r.With(middleware.RejectImpersonation).Post("/logout-all", router.authHandler.LogoutAll)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Post("/phone/send-verification", router.authHandler.SendPhoneVerification)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Post("/phone/verify", router.authHandler.VerifyPhone)
+					r.Get("/sessions", router.authHandler.ListSessions)
+					r.With(middleware.RejectImpersonation).Delete("/sessions/{session_id}", router.authHandler.RevokeSession)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Post("/mfa/enroll", router.authHandler.EnrollMFA)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Post("/mfa/confirm", router.authHandler.ConfirmMFA)
+					r.With(middleware.RejectImpersonation, middleware.RequireRecentAuth(5*time.Minute)).
+						Post("/mfa/disable", router.authHandler.DisableMFA)
+
+					r.With(middleware.RejectImpersonation).Route("/api-keys", func(r chi.Router) {
+						r.Get("/", router.apiKeyHandler.ListAPIKeys)
+						r.With(middleware.RequireRecentAuth(5*time.Minute)).Post("/", router.apiKeyHandler.CreateAPIKey)
+						r.Delete("/{api_key_id}", router.apiKeyHandler.RevokeAPIKey)
+					})
+				})
+			})
+
+		// Product Router group, public
+		r.Route("/products", func(r chi.Router) {
+			r.Get("/", router.productHandler.ListProducts)
+			r.Get("/{product_id}", router.productHandler.GetProduct)
+			r.Get("/slug/{slug}", router.productHandler.GetProductBySlug)
+			r.Get("/{product_id}/variants", router.variantHandler.GetProductVariants)
+		})
+
+		// Vendor Router group, the products are managed by their own vendor only
+		r.With(router.authMiddleware.Authenticate, middleware.ProfileMiddleware, middleware.RequirePermission("products:write")).
+			Route("/vendors/{user_id}/products", func(r chi.Router) {
+				r.Get("/", router.productHandler.ListVendorProducts)
+				r.Post("/", router.productHandler.CreateProduct)
+				r.Get("/{product_id}", router.productHandler.GetVendorProduct)
+				r.Put("/{product_id}", router.productHandler.UpdateProduct)
+				r.Post("/{product_id}/archive", router.productHandler.ArchiveProduct)
+				r.Put("/{product_id}/categories", router.productHandler.SetProductCategories)
+
+				r.Get("/{product_id}/variants", router.variantHandler.GetVendorProductVariants)
+				r.Post("/{product_id}/variants", router.variantHandler.CreateVariant)
+				r.Post("/{product_id}/variants/generate", router.variantHandler.GenerateVariants)
+				r.Put("/{product_id}/variants/{variant_id}", router.variantHandler.UpdateVariant)
+				r.Delete("/{product_id}/variants/{variant_id}", router.variantHandler.DeleteVariant)
+				r.Post("/{product_id}/options", router.variantHandler.CreateOption)
+				r.Delete("/{product_id}/options/{option_id}", router.variantHandler.DeleteOption)
+				r.Post("/{product_id}/options/{option_id}/values", router.variantHandler.AddOptionValue)
+				r.Delete("/{product_id}/options/{option_id}/values/{value_id}", router.variantHandler.DeleteOptionValue)
+			})
+
+		// Vendor locations Router group, the warehouses the stock of the vendor is kept at
+		r.With(router.authMiddleware.Authenticate, middleware.ProfileMiddleware, middleware.RequirePermission("products:write")).
+			Route("/vendors/{user_id}/locations", func(r chi.Router) {
+				r.Get("/", router.locationHandler.ListLocations)
+				r.Post("/", router.locationHandler.CreateLocation)
+				r.Get("/{location_id}", router.locationHandler.GetLocation)
+				r.Put("/{location_id}", router.locationHandler.UpdateLocation)
+				r.Delete("/{location_id}", router.locationHandler.DeleteLocation)
+			})
+
+		// Vendor inventory Router group, the stock is managed by the vendor of the variant only
+		r.With(router.authMiddleware.Authenticate, middleware.ProfileMiddleware, middleware.RequirePermission("products:write")).
+			Route("/vendors/{user_id}/inventory/{variant_id}", func(r chi.Router) {
+				r.Get("/", router.inventoryHandler.GetStock)
+				r.Post("/adjust", router.inventoryHandler.AdjustStock)
+				r.Post("/transfer", router.inventoryHandler.TransferStock)
+				r.Get("/movements", router.inventoryHandler.ListMovements)
+			})
+
+		// Inventory Router group, the stock of the checkouts is held by the services placing the orders
+		r.With(router.authMiddleware.Authenticate, middleware.RequirePermission("inventory:reserve")).
+			Route("/inventory/reservations", func(r chi.Router) {
+				r.Post("/", router.inventoryHandler.Reserve)
+				r.Post("/{reference}/commit", router.inventoryHandler.CommitReservations)
+				r.Post("/{reference}/release", router.inventoryHandler.ReleaseReservations)
+			})
+
+		// Category Router group, public
+		r.Route("/categories", func(r chi.Router) {
+			r.Get("/", router.categoryHandler.GetTree)
+			r.Get("/{category_id}", router.categoryHandler.GetCategory)
+			r.Get("/{category_id}/breadcrumbs", router.categoryHandler.GetBreadcrumbs)
+		})
+
+		// Admin Router group
+		r.With(router.authMiddleware.Authenticate).
+			Route("/admin", func(r chi.Router) {
+				r.With(middleware.RequirePermission("users:read")).
+					Get("/users", router.userHandler.ListUsers)
+				r.With(middleware.RequirePermission("users:read")).
+					Get("/users/{user_id}", router.userHandler.GetUserDetails)
+				r.With(middleware.RequirePermission("users:write")).
+					Post("/users/{user_id}/unlock", router.authHandler.UnlockUser)
+				r.With(middleware.RequirePermission("users:write")).
+					Post("/users/{user_id}/suspend", router.authHandler.SuspendUser)
+				r.With(middleware.RequirePermission("users:write")).
+					Post("/users/{user_id}/unsuspend", router.authHandler.UnsuspendUser)
+				r.With(middleware.RequirePermission("users:write")).
+					Post("/users/{user_id}/restore", router.userHandler.RestoreUser)
+				r.With(middleware.RequirePermission("users:impersonate"), middleware.RejectAPIKeys, middleware.RejectImpersonation).
+					Post("/users/{user_id}/impersonate", router.authHandler.ImpersonateUser)
+				r.With(middleware.RequirePermission("roles:write")).
+					Put("/users/{user_id}/role", router.userHandler.ChangeRole)
+				r.With(middleware.RequirePermission("roles:write")).
+					Get("/roles", router.roleHandler.GetRoles)
+
+				r.With(middleware.RequirePermission("categories:write")).Route("/categories", func(r chi.Router) {
+					r.Post("/", router.categoryHandler.CreateCategory)
+					r.Put("/{category_id}", router.categoryHandler.UpdateCategory)
+					r.Post("/{category_id}/move", router.categoryHandler.MoveCategory)
+					r.Delete("/{category_id}", router.categoryHandler.DeleteCategory)
+				})
 			})
 	})
 }
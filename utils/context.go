@@ -0,0 +1,24 @@
+package utils
+
+import "context"
+
+type contextKey string
+
+// UserContextKey const to hold the custom type for user context value.
+const UserContextKey = contextKey("user")
+
+// ActorContextKey const to hold the custom type for the impersonating admin context value.
+const ActorContextKey = contextKey("actor")
+
+// GetClaims returns the token claims stored in the context by the auth middleware.
+// While impersonating, they are the claims of the impersonated user.
+func GetClaims(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(UserContextKey).(*Claims)
+	return claims, ok
+}
+
+// GetActor returns the admin impersonating the user of the claims, stored in the context by the auth middleware.
+func GetActor(ctx context.Context) (*Actor, bool) {
+	actor, ok := ctx.Value(ActorContextKey).(*Actor)
+	return actor, ok
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Denylist is an in-memory set of revoked keys, each kept only until its expiry.
+type Denylist struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+// RevokedSessions holds the sessions whose access tokens must no longer be accepted.
+var RevokedSessions = NewDenylist()
+
+// NewDenylist initialize and return an empty Denylist
+func NewDenylist() *Denylist {
+	return &Denylist{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Add revokes the key for the given duration.
+func (d *Denylist) Add(key string, ttl time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Drop the entries which already expired before adding a new one
+	now := time.Now()
+	for k, expiresAt := range d.entries {
+		if now.After(expiresAt) {
+			delete(d.entries, k)
+		}
+	}
+
+	d.entries[key] = now.Add(ttl)
+}
+
+// Contains reports whether the key is revoked and not yet expired.
+func (d *Denylist) Contains(key string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	expiresAt, ok := d.entries[key]
+	return ok && time.Now().Before(expiresAt)
+}
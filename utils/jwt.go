@@ -6,36 +6,171 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+
+	"github.com/aslam-ep/go-e-commerce/config"
+)
+
+const (
+	// AccessTokenType is the typ claim value of the access tokens accepted by the API.
+	AccessTokenType = "access"
+	// MFAPendingTokenType is the typ claim value of the tokens which only allow completing a two-factor login.
+	MFAPendingTokenType = "mfa_pending"
+	// APIKeyType is the typ of the claims of a request authenticated by an API key, they are never signed.
+	APIKeyType = "api_key"
 )
 
-// GenerateToken generates a JWT token for a user with a specified expiration time.
-func GenerateToken(userID int64, secret string, expiry time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": strconv.Itoa(int(userID)),
-		"exp":     time.Now().Add(expiry).Unix(),
+// Claims represents the claims carried by the tokens issued by the API, the user id is the subject.
+// The permissions granted by the role are carried along, so any service verifying the token can enforce them.
+// The auth time is when the user last proved their credentials, the actor is set only while an admin acts as the subject.
+type Claims struct {
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Type        string   `json:"typ"`
+	SessionID   string   `json:"session_id,omitempty"`
+	AuthTime    int64    `json:"auth_time,omitempty"`
+	Actor       *Actor   `json:"act,omitempty"`
+	jwt.StandardClaims
+}
+
+// Actor represents the user acting on behalf of the subject of a token, as the act claim of RFC 8693.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// UserID returns the user id of the actor from its subject.
+func (a *Actor) UserID() (int, error) {
+	return strconv.Atoi(a.Subject)
+}
+
+// UserID returns the user id from the subject claim.
+func (c *Claims) UserID() (int, error) {
+	return strconv.Atoi(c.Subject)
+}
+
+// AuthenticatedWithin reports whether the user proved their credentials within the given duration.
+// Tokens without an auth time, like the impersonation ones, never count as recently authenticated.
+func (c *Claims) AuthenticatedWithin(d time.Duration) bool {
+	return c.AuthTime != 0 && time.Since(time.Unix(c.AuthTime, 0)) <= d
+}
+
+// GenerateToken generates a JWT access token for a user with a specified expiration time.
+// A non empty sessionID ties the token to the refresh token family it was issued from,
+// authTime is when the user last proved their credentials in that session.
+func GenerateToken(userID int64, role string, permissions []string, sessionID string, authTime time.Time, expiry time.Duration) (string, error) {
+	claims := &Claims{
+		Role:        role,
+		Permissions: permissions,
+		Type:        AccessTokenType,
+		SessionID:   sessionID,
+		AuthTime:    authTime.Unix(),
+		StandardClaims: jwt.StandardClaims{
+			Subject: strconv.FormatInt(userID, 10),
+		},
+	}
+
+	return SignToken(claims, expiry)
+}
+
+// GenerateImpersonationToken generates a JWT access token for a user, acted on by the given admin.
+// It isn't tied to a session, so it can't be refreshed.
+func GenerateImpersonationToken(userID int64, role string, permissions []string, actorID int64, expiry time.Duration) (string, error) {
+	claims := &Claims{
+		Role:        role,
+		Permissions: permissions,
+		Type:        AccessTokenType,
+		Actor: &Actor{
+			Subject: strconv.FormatInt(actorID, 10),
+		},
+		StandardClaims: jwt.StandardClaims{
+			Subject: strconv.FormatInt(userID, 10),
+		},
+	}
+
+	return SignToken(claims, expiry)
+}
+
+// GenerateMFAToken generates a JWT token which only allows completing the two-factor login of a user,
+// along with its jti which the login is tracked by.
+func GenerateMFAToken(userID int64, expiry time.Duration) (string, string, error) {
+	claims := &Claims{
+		Type: MFAPendingTokenType,
+		StandardClaims: jwt.StandardClaims{
+			Subject: strconv.FormatInt(userID, 10),
+		},
+	}
+
+	token, err := SignToken(claims, expiry)
+	if err != nil {
+		return "", "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token, claims.Id, nil
+}
+
+// SignToken fills the issuer, audience, timing and id claims and signs the token.
+func SignToken(claims *Claims, expiry time.Duration) (string, error) {
+	jti, err := GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.Issuer = config.AppConfig.JWTIssuer
+	claims.Audience = config.AppConfig.JWTAudience
+	claims.Id = jti
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = now.Add(expiry).Unix()
+
+	return SigningKeys.Sign(claims)
 }
 
 // ValidateToken validates a JWT token and returns the claims if valid.
-func ValidateToken(tokenStr, secret string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-
-		return []byte(secret), nil
-	})
+func ValidateToken(tokenStr string) (*Claims, error) {
+	// Registered claims are validated below, with leeway for the clock skew
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+
+	claims := &Claims{}
+	token, err := parser.ParseWithClaims(tokenStr, claims, SigningKeys.Keyfunc)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
+	if !token.Valid {
 		return nil, errors.New("invalid token")
 	}
 
+	if err := validateClaims(claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
+
+// validateClaims checks the registered claims against the configured issuer, audience and leeway.
+func validateClaims(claims *Claims) error {
+	now := time.Now().Unix()
+	leeway := int64(config.AppConfig.JWTLeeway)
+
+	if claims.ExpiresAt == 0 || now-leeway > claims.ExpiresAt {
+		return errors.New("token is expired")
+	}
+
+	if now+leeway < claims.NotBefore || now+leeway < claims.IssuedAt {
+		return errors.New("token is not valid yet")
+	}
+
+	if !claims.VerifyIssuer(config.AppConfig.JWTIssuer, true) {
+		return errors.New("invalid token issuer")
+	}
+
+	if !claims.VerifyAudience(config.AppConfig.JWTAudience, true) {
+		return errors.New("invalid token audience")
+	}
+
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// KeySet holds the key used to sign new tokens and every key accepted when verifying them.
+type KeySet struct {
+	signingKID       string
+	signingMethod    jwt.SigningMethod
+	signingKey       crypto.PrivateKey
+	verificationKeys map[string]verificationKey
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+	jwk    *JWK
+}
+
+// JWK represents a public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet represents the published set of verification keys.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// SigningKeys holds the keys used for the access tokens, loaded on startup.
+var SigningKeys *KeySet
+
+// LoadSigningKeys loads the signing key from the private key PEM file along with the
+// public key PEM files of the keys which are still accepted, and store them in SigningKeys.
+// Without a private key file tokens are signed with HS256 using the secret.
+func LoadSigningKeys(secret, privateKeyFile string, publicKeyFiles []string) error {
+	keySet := &KeySet{
+		verificationKeys: make(map[string]verificationKey),
+	}
+
+	if privateKeyFile == "" {
+		keySet.signingMethod = jwt.SigningMethodHS256
+		keySet.signingKey = []byte(secret)
+		keySet.signingKID = thumbprint(map[string]string{
+			"kty": "oct",
+			"k":   base64.RawURLEncoding.EncodeToString([]byte(secret)),
+		})
+		keySet.verificationKeys[keySet.signingKID] = verificationKey{
+			method: jwt.SigningMethodHS256,
+			key:    []byte(secret),
+		}
+	} else {
+		block, err := readPEM(privateKeyFile)
+		if err != nil {
+			return err
+		}
+
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			if privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return fmt.Errorf("failed to parse private key %s: %v", privateKeyFile, err)
+			}
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("unsupported private key in %s", privateKeyFile)
+		}
+
+		key, err := keySet.addVerificationKey(signer.Public())
+		if err != nil {
+			return fmt.Errorf("unsupported private key in %s: %v", privateKeyFile, err)
+		}
+
+		keySet.signingMethod = key.method
+		keySet.signingKey = privateKey
+		keySet.signingKID = key.jwk.Kid
+	}
+
+	// Keys of the previous rotations, so their tokens stay valid until they expire
+	for _, publicKeyFile := range publicKeyFiles {
+		block, err := readPEM(publicKeyFile)
+		if err != nil {
+			return err
+		}
+
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			if publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+				return fmt.Errorf("failed to parse public key %s: %v", publicKeyFile, err)
+			}
+		}
+
+		if _, err := keySet.addVerificationKey(publicKey); err != nil {
+			return fmt.Errorf("unsupported public key in %s: %v", publicKeyFile, err)
+		}
+	}
+
+	SigningKeys = keySet
+
+	return nil
+}
+
+// Sign signs the claims with the signing key and sets its kid header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signingMethod, claims)
+	token.Header["kid"] = k.signingKID
+
+	return token.SignedString(k.signingKey)
+}
+
+// Keyfunc resolves the verification key of a token from its kid header.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" && k.signingMethod == jwt.SigningMethodHS256 {
+		// Tokens signed before kid headers were introduced
+		kid = k.signingKID
+	}
+
+	key, ok := k.verificationKeys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.key, nil
+}
+
+// JWKS returns the public verification keys, symmetric keys are never published.
+func (k *KeySet) JWKS() *JWKSet {
+	jwks := &JWKSet{
+		Keys: []JWK{},
+	}
+
+	for _, key := range k.verificationKeys {
+		if key.jwk != nil {
+			jwks.Keys = append(jwks.Keys, *key.jwk)
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+// addVerificationKey stores the public key under its RFC 7638 thumbprint as kid.
+func (k *KeySet) addVerificationKey(publicKey crypto.PublicKey) (verificationKey, error) {
+	var key verificationKey
+
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		n := base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		key = verificationKey{
+			method: jwt.SigningMethodRS256,
+			key:    pub,
+			jwk: &JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				Kid: thumbprint(map[string]string{"kty": "RSA", "n": n, "e": e}),
+				N:   n,
+				E:   e,
+			},
+		}
+	case ed25519.PublicKey:
+		x := base64.RawURLEncoding.EncodeToString(pub)
+		key = verificationKey{
+			method: jwt.SigningMethodEdDSA,
+			key:    pub,
+			jwk: &JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: jwt.SigningMethodEdDSA.Alg(),
+				Kid: thumbprint(map[string]string{"kty": "OKP", "crv": "Ed25519", "x": x}),
+				Crv: "Ed25519",
+				X:   x,
+			},
+		}
+	default:
+		return key, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	k.verificationKeys[key.jwk.Kid] = key
+
+	return key, nil
+}
+
+// thumbprint computes the RFC 7638 thumbprint of the required JWK members.
+func thumbprint(members map[string]string) string {
+	// encoding/json sorts map keys, which gives the lexicographic order the RFC asks for
+	canonical, _ := json.Marshal(members)
+	digest := sha256.Sum256(canonical)
+
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// readPEM reads the first PEM block of the file.
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+
+	return block, nil
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// The example keys of RFC 7638 section 3.1 and RFC 8037 appendix A.3, with their thumbprints
+const (
+	rfc7638N          = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+	rfc7638Thumbprint = "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	rfc8037X          = "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+	rfc8037Thumbprint = "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+)
+
+func decodeBase64URL(t *testing.T, s string) []byte {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode %q: %v", s, err)
+	}
+	return data
+}
+
+func TestAddVerificationKeyThumbprints(t *testing.T) {
+	tests := []struct {
+		name      string
+		publicKey any
+		want      JWK
+	}{
+		{
+			name:      "RSA key of RFC 7638",
+			publicKey: &rsa.PublicKey{N: new(big.Int).SetBytes(decodeBase64URL(t, rfc7638N)), E: 65537},
+			want:      JWK{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: rfc7638Thumbprint, N: rfc7638N, E: "AQAB"},
+		},
+		{
+			name:      "Ed25519 key of RFC 8037",
+			publicKey: ed25519.PublicKey(decodeBase64URL(t, rfc8037X)),
+			want:      JWK{Kty: "OKP", Use: "sig", Alg: "EdDSA", Kid: rfc8037Thumbprint, Crv: "Ed25519", X: rfc8037X},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keySet := &KeySet{verificationKeys: make(map[string]verificationKey)}
+
+			key, err := keySet.addVerificationKey(tt.publicKey)
+			if err != nil {
+				t.Fatalf("addVerificationKey() error = %v", err)
+			}
+			if *key.jwk != tt.want {
+				t.Errorf("addVerificationKey() jwk = %+v, want %+v", *key.jwk, tt.want)
+			}
+			if _, ok := keySet.verificationKeys[tt.want.Kid]; !ok {
+				t.Errorf("key isn't stored under its thumbprint %q", tt.want.Kid)
+			}
+		})
+	}
+}
+
+func TestAddVerificationKeyUnsupported(t *testing.T) {
+	keySet := &KeySet{verificationKeys: make(map[string]verificationKey)}
+
+	if _, err := keySet.addVerificationKey([]byte("secret")); err == nil {
+		t.Error("addVerificationKey() of a symmetric key error = nil, want an error")
+	}
+}
+
+// writePEM writes the DER bytes to a PEM file in the test directory and returns its path
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// loadSigningKeys loads the keys like on startup and restores the previous ones after the test
+func loadSigningKeys(t *testing.T, secret, privateKeyFile string, publicKeyFiles []string) *KeySet {
+	t.Helper()
+
+	previous := SigningKeys
+	t.Cleanup(func() { SigningKeys = previous })
+
+	if err := LoadSigningKeys(secret, privateKeyFile, publicKeyFiles); err != nil {
+		t.Fatalf("LoadSigningKeys() error = %v", err)
+	}
+	return SigningKeys
+}
+
+func TestLoadSigningKeysJWKS(t *testing.T) {
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A key of a previous rotation, only its public key is kept
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaDER, err := x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keySet := loadSigningKeys(t, "secret",
+		writePEM(t, "signing.pem", "PRIVATE KEY", edDER),
+		[]string{writePEM(t, "previous.pem", "PUBLIC KEY", rsaDER)},
+	)
+
+	jwks := keySet.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kid >= jwks.Keys[1].Kid {
+		t.Errorf("JWKS() keys aren't sorted by kid: %q, %q", jwks.Keys[0].Kid, jwks.Keys[1].Kid)
+	}
+
+	algs := map[string]string{}
+	for _, key := range jwks.Keys {
+		algs[key.Kty] = key.Alg
+	}
+	if algs["OKP"] != "EdDSA" || algs["RSA"] != "RS256" {
+		t.Errorf("JWKS() algorithms = %v, want OKP EdDSA and RSA RS256", algs)
+	}
+	if keySet.signingKID == "" || keySet.signingMethod != jwt.SigningMethodEdDSA {
+		t.Errorf("signing key = %q %v, want the Ed25519 key", keySet.signingKID, keySet.signingMethod)
+	}
+}
+
+func TestLoadSigningKeysSecretNotPublished(t *testing.T) {
+	keySet := loadSigningKeys(t, "secret", "", nil)
+
+	if keys := keySet.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS() = %+v, want no keys for a secret", keys)
+	}
+}
+
+func TestKeySetSignAndKeyfunc(t *testing.T) {
+	keySet := loadSigningKeys(t, "secret", "", nil)
+
+	signed, err := keySet.Sign(jwt.StandardClaims{Subject: "1"})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"signed token", signed, false},
+		{"unknown kid", signWithHeader(t, jwt.SigningMethodHS256, "unknown", []byte("secret")), true},
+		{"other algorithm of the kid", signWithHeader(t, jwt.SigningMethodHS512, keySet.signingKID, []byte("secret")), true},
+		{"no kid before kid headers", signWithHeader(t, jwt.SigningMethodHS256, "", []byte("secret")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, keySet.Keyfunc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// signWithHeader signs a token with the method and the kid header, left out when empty
+func signWithHeader(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.StandardClaims{Subject: "1"})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
@@ -1,15 +1,243 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 
-// HashPassword hashes a password using bcrypt.
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher interface for hashing passwords into self describing strings
+type PasswordHasher interface {
+	// Hash hashes the password.
+	Hash(password string) (string, error)
+
+	// Verify compares the password with a hash produced by this hasher.
+	Verify(password, hashedPassword string) bool
+
+	// Supports reports whether the hash was produced by this kind of hasher.
+	Supports(hashedPassword string) bool
+
+	// NeedsRehash reports whether the hash was produced with other parameters than the current ones.
+	NeedsRehash(hashedPassword string) bool
+}
+
+// Argon2idParams holds the cost parameters of argon2id, memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams are the parameters recommended by OWASP for argon2id.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// NewArgon2idParams returns the argon2id parameters of the given costs along with the default salt and key lengths,
+// or an error if a cost is out of range, memory is in KiB.
+func NewArgon2idParams(memory, iterations, parallelism int) (Argon2idParams, error) {
+	if iterations < 1 || int64(iterations) > math.MaxUint32 {
+		return Argon2idParams{}, fmt.Errorf("argon2id iterations must be between 1 and %d, got %d", uint32(math.MaxUint32), iterations)
+	}
+	if parallelism < 1 || parallelism > math.MaxUint8 {
+		return Argon2idParams{}, fmt.Errorf("argon2id parallelism must be between 1 and %d, got %d", math.MaxUint8, parallelism)
+	}
+	// argon2id needs at least 8 KiB per lane
+	if memory < 8*parallelism || int64(memory) > math.MaxUint32 {
+		return Argon2idParams{}, fmt.Errorf("argon2id memory must be between %d and %d KiB, got %d", 8*parallelism, uint32(math.MaxUint32), memory)
+	}
+
+	params := DefaultArgon2idParams
+	params.Memory = uint32(memory)
+	params.Iterations = uint32(iterations)
+	params.Parallelism = uint8(parallelism)
+
+	return params, nil
+}
+
+// passwordHasher hashes the new passwords, while every hasher in passwordVerifiers can still verify old ones.
+var (
+	passwordHasher    PasswordHasher = NewArgon2idHasher(DefaultArgon2idParams)
+	passwordVerifiers                = []PasswordHasher{passwordHasher, NewBcryptHasher(bcrypt.DefaultCost)}
+)
+
+// ConfigurePasswordHasher selects the hasher used for the new passwords, one of argon2id or bcrypt.
+// Out of range costs are rejected, as hashing would fail or panic at the first login.
+func ConfigurePasswordHasher(algorithm string, argon2Params Argon2idParams, bcryptCost int) error {
+	if argon2Params.Iterations < 1 || argon2Params.Parallelism < 1 || argon2Params.Memory < 8*uint32(argon2Params.Parallelism) ||
+		argon2Params.SaltLength < 1 || argon2Params.KeyLength < 1 {
+		return fmt.Errorf("invalid argon2id parameters %+v", argon2Params)
+	}
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, bcryptCost)
+	}
+
+	argon2Hasher := NewArgon2idHasher(argon2Params)
+	bcryptHasher := NewBcryptHasher(bcryptCost)
+
+	switch algorithm {
+	case "argon2id":
+		passwordHasher = argon2Hasher
+	case "bcrypt":
+		passwordHasher = bcryptHasher
+	default:
+		return fmt.Errorf("unsupported password hasher %q", algorithm)
+	}
+
+	passwordVerifiers = []PasswordHasher{argon2Hasher, bcryptHasher}
+
+	return nil
+}
+
+// HashPassword hashes a password with the configured hasher.
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedPassword), err
+	return passwordHasher.Hash(password)
 }
 
 // CheckPasswordHash compares a hashed password with a plain text password.
 func CheckPasswordHash(password, hashedPassword string) bool {
+	for _, verifier := range passwordVerifiers {
+		if verifier.Supports(hashedPassword) {
+			return verifier.Verify(password, hashedPassword)
+		}
+	}
+
+	return false
+}
+
+// PasswordNeedsRehash reports whether the hash is outdated by the configured hasher or its parameters.
+func PasswordNeedsRehash(hashedPassword string) bool {
+	return !passwordHasher.Supports(hashedPassword) || passwordHasher.NeedsRehash(hashedPassword)
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher initialize and return a PasswordHasher producing argon2id PHC strings
+func NewArgon2idHasher(params Argon2idParams) PasswordHasher {
+	return &argon2idHasher{params: params}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(password, hashedPassword string) bool {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return false
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (h *argon2idHasher) Supports(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$argon2id$")
+}
+
+func (h *argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, salt, _, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		params.KeyLength != h.params.KeyLength ||
+		uint32(len(salt)) != h.params.SaltLength
+}
+
+// decodeArgon2id parses an argon2id PHC string into its parameters, salt and key.
+func decodeArgon2id(hashedPassword string) (*Argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.New("unsupported argon2id version")
+	}
+
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id salt")
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id key")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return &params, salt, key, nil
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher initialize and return a PasswordHasher producing bcrypt hashes
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	// bcrypt only uses the first 72 bytes, longer passwords are rejected rather than silently truncated
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(hashedPassword), err
+}
+
+func (h *bcryptHasher) Verify(password, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+func (h *bcryptHasher) Supports(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+func (h *bcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != h.cost
+}
@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams are cheap parameters, the costs don't matter to the format
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHashFormat(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if want := "$argon2id$v=19$m=64,t=1,p=2$"; !strings.HasPrefix(hash, want) {
+		t.Errorf("Hash() = %q, want the prefix %q", hash, want)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatalf("decodeArgon2id() error = %v", err)
+	}
+	if *params != testArgon2idParams || len(salt) != 16 || len(key) != 32 {
+		t.Errorf("decodeArgon2id() = %+v with %d byte salt and %d byte key, want %+v", *params, len(salt), len(key), testArgon2idParams)
+	}
+
+	other, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if other == hash {
+		t.Error("Hash() gave the same hash twice, want a new salt every time")
+	}
+}
+
+func TestArgon2idVerify(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	parts := strings.Split(hash, "$")
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"right password", "password", hash, true},
+		{"wrong password", "Password", hash, false},
+		{"empty password", "", hash, false},
+		{"other version", "password", strings.Replace(hash, "v=19", "v=16", 1), false},
+		{"other parameters", "password", strings.Replace(hash, "t=1", "t=2", 1), false},
+		{"missing part", "password", strings.Join(parts[:5], "$"), false},
+		{"invalid salt", "password", strings.Replace(hash, parts[4], "!!", 1), false},
+		{"invalid key", "password", strings.Replace(hash, parts[5], "!!", 1), false},
+		{"bcrypt hash", "password", "$2a$04$" + strings.Repeat("a", 53), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasher.Verify(tt.password, tt.hash); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	hashWith := func(change func(p *Argon2idParams)) string {
+		params := testArgon2idParams
+		change(&params)
+
+		hash, err := NewArgon2idHasher(params).Hash("password")
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		return hash
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current parameters", hashWith(func(p *Argon2idParams) {}), false},
+		{"other memory", hashWith(func(p *Argon2idParams) { p.Memory = 128 }), true},
+		{"other iterations", hashWith(func(p *Argon2idParams) { p.Iterations = 2 }), true},
+		{"other parallelism", hashWith(func(p *Argon2idParams) { p.Parallelism = 1 }), true},
+		{"other salt length", hashWith(func(p *Argon2idParams) { p.SaltLength = 8 }), true},
+		{"other key length", hashWith(func(p *Argon2idParams) { p.KeyLength = 16 }), true},
+		{"malformed hash", "$argon2id$v=19$m=64", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcryptNeedsRehash(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+		hash   string
+		want   bool
+	}{
+		{"same cost", hasher, hash, false},
+		{"other cost", NewBcryptHasher(bcrypt.MinCost + 1), hash, true},
+		{"malformed hash", hasher, "$2a$", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewArgon2idParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		memory      int
+		iterations  int
+		parallelism int
+		wantErr     bool
+	}{
+		{"defaults", 64 * 1024, 3, 2, false},
+		{"least memory of the lanes", 16, 1, 2, false},
+		{"most parallelism", 8 * 255, 1, 255, false},
+		{"too little memory for the lanes", 15, 1, 2, true},
+		{"no iterations", 64 * 1024, 0, 2, true},
+		{"negative iterations", 64 * 1024, -1, 2, true},
+		{"no parallelism", 64 * 1024, 3, 0, true},
+		{"too much parallelism", 64 * 1024, 3, 256, true},
+		{"negative memory", -1, 3, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := NewArgon2idParams(tt.memory, tt.iterations, tt.parallelism)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewArgon2idParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if params.Memory != uint32(tt.memory) || params.Iterations != uint32(tt.iterations) || params.Parallelism != uint8(tt.parallelism) {
+				t.Errorf("NewArgon2idParams() = %+v, want the given costs", params)
+			}
+			if params.SaltLength != DefaultArgon2idParams.SaltLength || params.KeyLength != DefaultArgon2idParams.KeyLength {
+				t.Errorf("NewArgon2idParams() = %+v, want the default salt and key lengths", params)
+			}
+		})
+	}
+}
+
+// restorePasswordHasher puts the hashers back as they were before the test
+func restorePasswordHasher(t *testing.T) {
+	hasher, verifiers := passwordHasher, passwordVerifiers
+	t.Cleanup(func() {
+		passwordHasher, passwordVerifiers = hasher, verifiers
+	})
+}
+
+func TestConfigurePasswordHasher(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithm  string
+		params     func(p *Argon2idParams)
+		bcryptCost int
+		wantErr    bool
+	}{
+		{"argon2id", "argon2id", func(p *Argon2idParams) {}, bcrypt.MinCost, false},
+		{"bcrypt", "bcrypt", func(p *Argon2idParams) {}, bcrypt.MaxCost, false},
+		{"unknown algorithm", "scrypt", func(p *Argon2idParams) {}, bcrypt.MinCost, true},
+		{"bcrypt cost too low", "bcrypt", func(p *Argon2idParams) {}, bcrypt.MinCost - 1, true},
+		{"bcrypt cost too high", "bcrypt", func(p *Argon2idParams) {}, bcrypt.MaxCost + 1, true},
+		{"no argon2id iterations", "argon2id", func(p *Argon2idParams) { p.Iterations = 0 }, bcrypt.MinCost, true},
+		{"no argon2id parallelism", "argon2id", func(p *Argon2idParams) { p.Parallelism = 0 }, bcrypt.MinCost, true},
+		{"too little argon2id memory", "argon2id", func(p *Argon2idParams) { p.Memory = 15 }, bcrypt.MinCost, true},
+		{"no argon2id salt", "argon2id", func(p *Argon2idParams) { p.SaltLength = 0 }, bcrypt.MinCost, true},
+		{"no argon2id key", "argon2id", func(p *Argon2idParams) { p.KeyLength = 0 }, bcrypt.MinCost, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restorePasswordHasher(t)
+
+			params := testArgon2idParams
+			tt.params(&params)
+
+			err := ConfigurePasswordHasher(tt.algorithm, params, tt.bcryptCost)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConfigurePasswordHasher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordMigration(t *testing.T) {
+	restorePasswordHasher(t)
+
+	// Hashed while bcrypt was configured
+	if err := ConfigurePasswordHasher("bcrypt", testArgon2idParams, bcrypt.MinCost); err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := HashPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConfigurePasswordHasher("argon2id", testArgon2idParams, bcrypt.MinCost); err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := HashPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		hash       string
+		wantValid  bool
+		wantRehash bool
+	}{
+		{"bcrypt hash of the previous hasher", bcryptHash, true, true},
+		{"argon2id hash of the current hasher", argon2Hash, true, false},
+		{"unknown hash", "$1$salt$hash", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPasswordHash("password", tt.hash); got != tt.wantValid {
+				t.Errorf("CheckPasswordHash() = %v, want %v", got, tt.wantValid)
+			}
+			if got := PasswordNeedsRehash(tt.hash); got != tt.wantRehash {
+				t.Errorf("PasswordNeedsRehash() = %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+}
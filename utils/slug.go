@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// slugPattern matches the valid slugs, like "blue-cotton-shirt-2"
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IsSlug reports whether the value is made of lowercase letters and digits separated by single dashes.
+func IsSlug(value string) bool {
+	return slugPattern.MatchString(value)
+}
+
+// Slugify derives a slug like "blue-cotton-shirt" from a name, keeping only the ASCII letters and digits.
+// The fallback is used when nothing is left.
+func Slugify(name, fallback string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	slug := b.String()
+	if len(slug) > 200 {
+		slug = strings.TrimRight(slug[:200], "-")
+	}
+	if slug == "" {
+		slug = fallback
+	}
+
+	return slug
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890" in base32
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// The 8 digit values of RFC 6238 appendix B, truncated to their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(%q) at %d = false, want true", tt.code, tt.unix)
+			continue
+		}
+		if want := tt.unix / totpPeriod; step != want {
+			t.Errorf("ValidateTOTP(%q) at %d step = %d, want %d", tt.code, tt.unix, step, want)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	// 287082 is the code of the step 1, from 30 to 59
+	const code = "287082"
+
+	tests := []struct {
+		name string
+		unix int64
+		ok   bool
+	}{
+		{"first second of the step", 30, true},
+		{"last second of the step", 59, true},
+		{"first second of the previous step", 0, true},
+		{"last second of the next step", 89, true},
+		{"first second of two steps after", 90, false},
+		{"last second of two steps after", 119, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, code, time.Unix(tt.unix, 0))
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP at %d = %v, want %v", tt.unix, ok, tt.ok)
+			}
+			if ok && step != 1 {
+				t.Errorf("ValidateTOTP at %d step = %d, want 1", tt.unix, step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPInvalid(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"wrong code", rfc6238Secret, "287083"},
+		{"short code", rfc6238Secret, "28708"},
+		{"long code", rfc6238Secret, "2870820"},
+		{"empty code", rfc6238Secret, ""},
+		{"invalid secret", "not base32!", "287082"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+				t.Errorf("ValidateTOTP(%q, %q) = true, want false", tt.secret, tt.code)
+			}
+		})
+	}
+}